@@ -0,0 +1,104 @@
+package redef
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// A baseline, as named by -baseline, lists the fingerprints of known
+// shadows, one per line, which are then no longer reported:
+//
+//	# redef baseline
+//	example.com/mono/store store.go (*DB).Get err +12
+//	example.com/mono/store store.go init ctx +3
+//
+// Blank lines and lines starting with "#" are ignored. The redef
+// command writes such a file with -write-baseline.
+
+var baselineCache struct {
+	sync.Mutex
+	path string
+	set  map[string]bool
+	err  error
+}
+
+// loadBaseline reads the baseline file at name. The result is cached,
+// since run is invoked once per package.
+func loadBaseline(name string) (map[string]bool, error) {
+	baselineCache.Lock()
+	defer baselineCache.Unlock()
+
+	if baselineCache.path != name {
+		baselineCache.path = name
+		baselineCache.set, baselineCache.err = readBaseline(name)
+	}
+
+	return baselineCache.set, baselineCache.err
+}
+
+func readBaseline(name string) (map[string]bool, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("redef: reading baseline: %w", err)
+	}
+
+	set := make(map[string]bool)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			set[line] = true
+		}
+	}
+	if err = sc.Err(); err != nil {
+		return nil, fmt.Errorf("redef: reading baseline %s: %w", name, err)
+	}
+
+	return set, nil
+}
+
+// fingerprint identifies f for -baseline by package, file name,
+// enclosing top-level declaration, inner variable name and line
+// relative to that declaration, e.g. "example.com/p a.go (*T).Get err
+// +12". Unlike a position, it survives edits elsewhere in the file.
+func (c *checker) fingerprint(f finding) string {
+	pos := f.ident.Pos()
+	decl, line := "_", c.pass.Fset.Position(pos).Line
+	if file := c.fileOf(pos); file != nil {
+		for _, d := range file.Decls {
+			if pos < d.Pos() || d.End() <= pos {
+				continue
+			}
+			line -= c.pass.Fset.Position(d.Pos()).Line
+			decl = declName(d, pos)
+			break
+		}
+	}
+
+	return fmt.Sprintf("%s %s %s %s +%d", c.pass.Pkg.Path(),
+		filepath.Base(c.pass.Fset.Position(pos).Filename), decl, f.ident.Name, line)
+}
+
+// declName names the top-level declaration d holding pos: a function
+// as by qualifiedName, or the first variable of the spec whose
+// initializer holds pos, as in "var handler = func() { ... }".
+func declName(d ast.Decl, pos token.Pos) string {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		return qualifiedName(d)
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			if vs, ok := spec.(*ast.ValueSpec); ok && vs.Pos() <= pos && pos < vs.End() {
+				return vs.Names[0].Name
+			}
+		}
+	}
+	return "_"
+}
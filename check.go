@@ -0,0 +1,136 @@
+package redef
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Diagnostic is a single finding returned by Check.
+type Diagnostic struct {
+	// Pos and End delimit the identifier of the inner variable.
+	Pos, End token.Position
+
+	// Category is one of the stable redef.* codes, such as
+	// "redef.shadow" or "redef.shadow.err".
+	Category string
+
+	// Message is the human-readable text that the Analyzer reports.
+	Message string
+
+	// Name is the name of the inner variable.
+	Name string
+
+	// Outer is where the shadowed (or otherwise related) variable is
+	// declared. It is the zero Position for objects without one.
+	Outer token.Position
+
+	// Note, if not empty, explains why the finding is less certain
+	// than usual, typically because the type of a variable involved
+	// could not be determined.
+	Note string
+}
+
+// Check runs the same detection as Analyzer over the type-checked files
+// of a single package, for tools that embed redef without going through
+// an analysis driver. info must have at least its Defs, Uses, Implicits
+// and Scopes maps populated. The Analyzer's flags, as currently set,
+// apply as usual.
+//
+// Findings are returned in source order, one per shadowing site;
+// -cluster-by-outer does not apply here.
+func Check(fset *token.FileSet, files []*ast.File, info *types.Info) ([]Diagnostic, error) {
+	var pkg *types.Package
+	for _, obj := range info.Defs {
+		if obj != nil && obj.Pkg() != nil {
+			pkg = obj.Pkg()
+			break
+		}
+	}
+	if pkg == nil {
+		// Nothing is declared, so nothing can be redefined.
+		return nil, nil
+	}
+
+	pass := &analysis.Pass{
+		Analyzer:  Analyzer,
+		Fset:      fset,
+		Files:     files,
+		Pkg:       pkg,
+		TypesInfo: info,
+	}
+	c, err := check(pass, inspector.New(files))
+	if err != nil {
+		return nil, err
+	}
+
+	var diags []Diagnostic
+	for _, f := range c.sorted(c.overThreshold()) {
+		diags = append(diags, Diagnostic{
+			Pos:      fset.Position(f.ident.Pos()),
+			End:      fset.Position(f.ident.End()),
+			Category: f.kind,
+			Message:  f.message,
+			Name:     f.ident.Name,
+			Outer:    fset.Position(f.outer.Pos()),
+			Note:     typeNote(f),
+		})
+	}
+
+	return diags, nil
+}
+
+// CheckFile is like Check, but for a single parsed file that has not
+// been type-checked, such as one open in an editor. The file is
+// type-checked with conf, which may be nil to use the default importer,
+// and type errors do not stop the check: go/types still resolves every
+// scope, so shadows are found as usual, but categories that depend on
+// types, such as redef.shadow.err or redef.type-change, may be missed
+// or guessed from names alone. Findings involving a variable of unknown
+// type carry a Note saying so.
+//
+// conf is not modified; its Error function, if any, is still called.
+func CheckFile(fset *token.FileSet, file *ast.File, conf *types.Config) ([]Diagnostic, error) {
+	var cfg types.Config
+	if conf != nil {
+		cfg = *conf
+	}
+	if cfg.Importer == nil {
+		cfg.Importer = importer.Default()
+	}
+	report := cfg.Error
+	cfg.Error = func(err error) {
+		if report != nil {
+			report(err)
+		}
+	}
+
+	info := &types.Info{
+		Defs:         make(map[*ast.Ident]types.Object),
+		Uses:         make(map[*ast.Ident]types.Object),
+		Implicits:    make(map[ast.Node]types.Object),
+		Scopes:       make(map[ast.Node]*types.Scope),
+		Types:        make(map[ast.Expr]types.TypeAndValue),
+		FileVersions: make(map[*ast.File]string),
+	}
+	// Errors went to cfg.Error; what was resolved is still in info.
+	cfg.Check(file.Name.Name, fset, []*ast.File{file}, info)
+
+	return Check(fset, []*ast.File{file}, info)
+}
+
+// typeNote returns a Diagnostic Note if the type of the inner or outer
+// variable of f could not be determined, and "" otherwise.
+func typeNote(f finding) string {
+	for _, obj := range []types.Object{f.inner, f.outer} {
+		if v, ok := obj.(*types.Var); ok && v.Type() == types.Typ[types.Invalid] {
+			return fmt.Sprintf("the type of %q could not be determined, so the category may be inaccurate", v.Name())
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/JesseCoretta/go-redef"
+	"golang.org/x/tools/go/analysis/checker"
+)
+
+// writeBaseline writes the fingerprints of every finding of the root
+// packages to the file name, sorted and without duplicates, for use
+// with -baseline.
+func writeBaseline(name string, roots []*checker.Action) error {
+	var prints []string
+	for _, act := range roots {
+		if res, ok := act.Result.(*redef.Result); ok && res != nil {
+			for _, s := range res.Shadows {
+				prints = append(prints, s.Fingerprint)
+			}
+		}
+	}
+	slices.Sort(prints)
+	prints = slices.Compact(prints)
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "# redef baseline: known shadows, not reported when run with -baseline.")
+	fmt.Fprintln(w, "# Fields: package, file, declaration, variable, line within the declaration.")
+	for _, p := range prints {
+		fmt.Fprintln(w, p)
+	}
+
+	if err = w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/JesseCoretta/go-redef"
+	"golang.org/x/tools/go/analysis/checker"
+)
+
+// buildConfig is one combination of build tags and GOOS under which
+// the packages are loaded and analyzed. Files excluded by one
+// configuration's build constraints may be included by another.
+type buildConfig struct {
+	tags, goos string
+}
+
+// String describes bc for error messages, or is empty for the default
+// configuration.
+func (bc buildConfig) String() string {
+	var parts []string
+	if bc.goos != "" {
+		parts = append(parts, "GOOS="+bc.goos)
+	}
+	if bc.tags != "" {
+		parts = append(parts, "-tags="+bc.tags)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// tagSets collects the values of a repeated -tags flag.
+type tagSets []string
+
+func (ts tagSets) String() string {
+	return strings.Join(ts, " ")
+}
+
+func (ts *tagSets) Set(value string) error {
+	*ts = append(*ts, value)
+	return nil
+}
+
+// buildConfigs returns every combination of the -tags sets and -goos
+// values, or the single default configuration if neither was given.
+func (d *driver) buildConfigs() (configs []buildConfig) {
+	tags := []string(d.tags)
+	if len(tags) == 0 {
+		tags = []string{""}
+	}
+	goos := []string{""}
+	if d.goos != "" {
+		goos = strings.Split(d.goos, ",")
+	}
+
+	for _, g := range goos {
+		for _, t := range tags {
+			configs = append(configs, buildConfig{tags: t, goos: strings.TrimSpace(g)})
+		}
+	}
+	return
+}
+
+// mergedDiagnostic is a diagnostic of one of several analyses of the
+// same packages.
+type mergedDiagnostic struct {
+	pkg      string
+	posn     token.Position
+	category string
+	message  string
+}
+
+// mergeDiagnostics returns the diagnostics of roots in position order,
+// reporting each one once even though a file shared by several build
+// configurations is analyzed under each of them.
+func mergeDiagnostics(roots []*checker.Action) []mergedDiagnostic {
+	type key struct {
+		posn    string
+		message string
+	}
+
+	var merged []mergedDiagnostic
+	seen := make(map[key]bool)
+	for _, act := range roots {
+		for _, d := range act.Diagnostics {
+			posn := act.Package.Fset.Position(d.Pos)
+			if k := (key{posn.String(), d.Message}); !seen[k] {
+				seen[k] = true
+				merged = append(merged, mergedDiagnostic{act.Package.ID, posn, d.Category, d.Message})
+			}
+		}
+	}
+
+	slices.SortStableFunc(merged, func(a, b mergedDiagnostic) int {
+		return cmp.Or(
+			cmp.Compare(a.posn.Filename, b.posn.Filename),
+			cmp.Compare(a.posn.Line, b.posn.Line),
+			cmp.Compare(a.posn.Column, b.posn.Column),
+		)
+	})
+	return merged
+}
+
+// printMergedText prints the merged diagnostics of roots to w, in the
+// same form as checker.Graph.PrintText without context lines.
+func printMergedText(w io.Writer, roots []*checker.Action) {
+	for _, d := range mergeDiagnostics(roots) {
+		fmt.Fprintf(w, "%s: %s\n", d.posn, d.message)
+	}
+}
+
+// printMergedJSON prints the merged diagnostics of roots to w, in the
+// same shape as checker.Graph.PrintJSON.
+func printMergedJSON(w io.Writer, roots []*checker.Action) error {
+	type jsonDiagnostic struct {
+		Category string `json:"category,omitempty"`
+		Posn     string `json:"posn"`
+		Message  string `json:"message"`
+	}
+
+	tree := make(map[string]map[string][]jsonDiagnostic)
+	for _, act := range roots {
+		if tree[act.Package.ID] == nil {
+			tree[act.Package.ID] = make(map[string][]jsonDiagnostic)
+		}
+	}
+	for _, d := range mergeDiagnostics(roots) {
+		name := redef.Analyzer.Name
+		tree[d.pkg][name] = append(tree[d.pkg][name], jsonDiagnostic{d.category, d.posn.String(), d.message})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(tree)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"go/format"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis/checker"
+)
+
+// edit is a text edit resolved to byte offsets within a file.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// renamePrefix starts the message of the fixes renaming a shadow, the
+// only ones the analyzer offers where renaming is known to be safe.
+// Other fixes, such as turning ":=" into "=", change what the code
+// does, so they are left for a person to review.
+const renamePrefix = "Rename inner "
+
+// applyFixes writes the suggested rename of every diagnostic of the
+// root packages back to the files concerned. Fixes found by both a
+// package and its test variant are applied once; a fix overlapping one
+// already accepted is dropped as a whole, so that no file is left half
+// renamed.
+func applyFixes(roots []*checker.Action) error {
+	accepted := make(map[string][]edit)
+	for _, act := range roots {
+		fset := act.Package.Fset
+	fixes:
+		for _, d := range act.Diagnostics {
+			if len(d.SuggestedFixes) == 0 || !strings.HasPrefix(d.SuggestedFixes[0].Message, renamePrefix) {
+				continue
+			}
+
+			pending := make(map[string][]edit)
+			for _, te := range d.SuggestedFixes[0].TextEdits {
+				tf := fset.File(te.Pos)
+				end := te.End
+				if !end.IsValid() {
+					end = te.Pos
+				}
+				e := edit{tf.Offset(te.Pos), tf.Offset(end), string(te.NewText)}
+				for _, prev := range accepted[tf.Name()] {
+					if prev == e {
+						continue fixes // already accepted via another package variant
+					}
+					if e.start < prev.end && prev.start < e.end {
+						continue fixes
+					}
+				}
+				pending[tf.Name()] = append(pending[tf.Name()], e)
+			}
+			for name, edits := range pending {
+				accepted[name] = append(accepted[name], edits...)
+			}
+		}
+	}
+
+	for name, edits := range accepted {
+		if err := rewriteFile(name, edits); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rewriteFile applies the non-overlapping edits to the file name and
+// reformats the result.
+func rewriteFile(name string, edits []edit) error {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(edits, func(a, b edit) int {
+		return cmp.Compare(a.start, b.start)
+	})
+
+	var out bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		out.Write(src[last:e.start])
+		out.WriteString(e.text)
+		last = e.end
+	}
+	out.Write(src[last:])
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting %s after applying fixes: %w", name, err)
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, formatted, info.Mode().Perm())
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"golang.org/x/tools/go/analysis/checker"
+)
+
+// reviewComment is a pull request review comment in the form accepted
+// by the GitHub REST API, for a bot to post as part of a review.
+type reviewComment struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line,omitempty"`
+	Line      int    `json:"line"`
+	Side      string `json:"side"`
+	Body      string `json:"body"`
+}
+
+// githubSuggestions returns a review comment for every diagnostic of
+// the root packages that carries a fix. The fix is rendered as a
+// suggestion block replacing the whole lines it touches, so that it
+// can be applied with one click. Paths are made relative to dir.
+func githubSuggestions(roots []*checker.Action, dir string) ([]reviewComment, error) {
+	comments := []reviewComment{}
+	sources := make(map[string][]byte)
+	for _, act := range roots {
+		fset := act.Package.Fset
+		for _, d := range act.Diagnostics {
+			if len(d.SuggestedFixes) == 0 {
+				continue
+			}
+			fix := d.SuggestedFixes[0]
+
+			// A suggestion replaces one contiguous range of lines
+			// in one file; rename fixes never leave the file.
+			tf := fset.File(d.Pos)
+			var edits []edit
+			first, last := tf.Line(d.Pos), tf.Line(d.Pos)
+			for _, te := range fix.TextEdits {
+				if fset.File(te.Pos) != tf {
+					edits = nil
+					break
+				}
+				end := te.End
+				if !end.IsValid() {
+					end = te.Pos
+				}
+				edits = append(edits, edit{tf.Offset(te.Pos), tf.Offset(end), string(te.NewText)})
+				first, last = min(first, tf.Line(te.Pos)), max(last, tf.Line(end))
+			}
+			if edits == nil {
+				continue
+			}
+
+			src, ok := sources[tf.Name()]
+			if !ok {
+				var err error
+				if src, err = os.ReadFile(tf.Name()); err != nil {
+					return nil, err
+				}
+				sources[tf.Name()] = src
+			}
+
+			start := tf.Offset(tf.LineStart(first))
+			end := len(src)
+			if last < tf.LineCount() {
+				end = tf.Offset(tf.LineStart(last+1)) - 1
+			}
+			slices.SortFunc(edits, func(a, b edit) int { return a.start - b.start })
+
+			var lines bytes.Buffer
+			at := start
+			for _, e := range edits {
+				lines.Write(src[at:e.start])
+				lines.WriteString(e.text)
+				at = e.end
+			}
+			lines.Write(src[at:end])
+
+			path, err := filepath.Rel(dir, tf.Name())
+			if err != nil {
+				path = tf.Name()
+			}
+			c := reviewComment{
+				Path: filepath.ToSlash(path),
+				Line: last,
+				Side: "RIGHT",
+				Body: fmt.Sprintf("%s\n\n%s:\n\n```suggestion\n%s\n```", d.Message, fix.Message, lines.Bytes()),
+			}
+			if first != last {
+				c.StartLine = first
+			}
+			if !slices.Contains(comments, c) {
+				comments = append(comments, c)
+			}
+		}
+	}
+
+	return comments, nil
+}
+
+// writeGitHubSuggestions writes the review comments for roots to w as
+// a JSON array.
+func writeGitHubSuggestions(w io.Writer, roots []*checker.Action) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	comments, err := githubSuggestions(roots, dir)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(comments)
+}
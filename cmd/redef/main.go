@@ -1,10 +1,340 @@
+// Command redef reports unnecessary variable redefinitions (shadowing)
+// in the named packages.
+//
+// Besides the analyzer's own flags (see -help), it supports driver-level
+// options which need to see the results of every package in the run,
+// such as -report-unused-rules. When invoked by "go vet -vettool", it
+// defers to the standard unitchecker protocol instead.
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
 	"github.com/JesseCoretta/go-redef"
-	"golang.org/x/tools/go/analysis/singlechecker"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/analysis/unitchecker"
+	"golang.org/x/tools/go/packages"
 )
 
 func main() {
-	singlechecker.Main(redef.Analyzer)
+	if vetMode(os.Args[1:]) {
+		unitchecker.Main(redef.Analyzer)
+	}
+
+	d := &driver{stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(d.run(os.Args[1:]))
+}
+
+// vetMode reports whether args look like an invocation by the go
+// command's vet driver rather than by a user.
+func vetMode(args []string) bool {
+	for _, arg := range args {
+		if arg == "-flags" || strings.HasPrefix(arg, "-V") {
+			return true
+		}
+	}
+	return len(args) == 1 && strings.HasSuffix(args[0], ".cfg")
+}
+
+// driver loads packages, runs the analyzer over them and prints the
+// outcome. Its env field lets tests load packages from a GOPATH-style
+// testdata tree.
+type driver struct {
+	stdout, stderr io.Writer
+	env            []string
+
+	json              bool
+	context           int
+	tests             bool
+	reportUnusedRules bool
+	metricsOut        string
+	writeBaseline     string
+	fix               bool
+	githubSuggestions bool
+	errorCategories   categorySet
+	tags              tagSets
+	goos              string
+}
+
+// Exit codes, as used by the standard analysis drivers.
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitDiagnostics = 3
+)
+
+func (d *driver) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("redef", flag.ContinueOnError)
+	fs.SetOutput(d.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "redef: %s\n\nUsage: redef [-flag] [package]\n\nFlags:\n",
+			redef.Analyzer.Doc)
+		fs.PrintDefaults()
+	}
+
+	redef.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+
+	fs.BoolVar(&d.json, "json", false, "emit JSON output")
+	fs.IntVar(&d.context, "c", -1, "display offending line with this many lines of context")
+	fs.BoolVar(&d.tests, "test", true, "indicates whether test files should be analyzed, too")
+	fs.BoolVar(&d.reportUnusedRules, "report-unused-rules", false,
+		"report enabled allow-* rules that never suppressed anything")
+	fs.StringVar(&d.metricsOut, "metrics-out", "",
+		"write per-package shadow counts to this file in Prometheus text format")
+	fs.StringVar(&d.writeBaseline, "write-baseline", "",
+		"write the current findings to this file for -baseline, instead of reporting them")
+	fs.BoolVar(&d.fix, "fix", false,
+		"apply the suggested renames where they are known to be safe; other suggested fixes are only reported")
+	fs.BoolVar(&d.githubSuggestions, "github-suggestions", false,
+		"write the suggested renames to stdout as GitHub review comments with suggestion blocks")
+	fs.Var(&d.tags, "tags",
+		"comma-separated build tags; repeat the flag to analyze each set of tags in turn")
+	fs.StringVar(&d.goos, "goos", "",
+		"comma-separated GOOS values to analyze in turn, instead of the host's")
+	fs.Var(&d.errorCategories, "error-categories",
+		"comma-separated categories (e.g. redef.shadow.err) that alone cause a non-zero exit, besides redef.sync; others are warnings")
+
+	return fs
+}
+
+func (d *driver) run(args []string) int {
+	fs := d.flagSet()
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitError
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+
+	// Mirror explicitly given analyzer flags into the analyzer's own
+	// FlagSet, so that it can tell them apart from -config defaults.
+	fs.Visit(func(f *flag.Flag) {
+		if redef.Analyzer.Flags.Lookup(f.Name) != nil {
+			redef.Analyzer.Flags.Set(f.Name, f.Value.String())
+		}
+	})
+	if d.writeBaseline != "" {
+		// The new baseline records every finding, including
+		// those in any old one.
+		redef.Analyzer.Flags.Set("baseline", "")
+	}
+
+	var graphs []*checker.Graph
+	var roots []*checker.Action
+	for _, bc := range d.buildConfigs() {
+		pkgs, err := d.load(fs.Args(), bc)
+		if err != nil {
+			fmt.Fprintf(d.stderr, "redef: %v%s\n", err, bc)
+			return exitError
+		}
+
+		graph, err := checker.Analyze([]*analysis.Analyzer{redef.Analyzer}, pkgs, nil)
+		if err != nil {
+			fmt.Fprintf(d.stderr, "redef: %v%s\n", err, bc)
+			return exitError
+		}
+		graphs = append(graphs, graph)
+		roots = append(roots, graph.Roots...)
+	}
+
+	var err error
+	code := exitOK
+	switch {
+	case d.writeBaseline != "":
+		err = writeBaseline(d.writeBaseline, roots)
+	case d.githubSuggestions:
+		err = writeGitHubSuggestions(d.stdout, roots)
+	case d.json && redef.Analyzer.Flags.Lookup("summary").Value.String() == "true":
+		err = writeSummaryJSON(d.stdout, roots)
+	case d.json && len(graphs) == 1:
+		err = graphs[0].PrintJSON(d.stdout)
+	case d.json:
+		err = printMergedJSON(d.stdout, roots)
+	default:
+		if len(graphs) == 1 {
+			err = graphs[0].PrintText(d.stderr, d.context)
+		} else {
+			printMergedText(d.stderr, roots)
+		}
+		if hasDiagnostics(roots, d.errorCategories) {
+			code = exitDiagnostics
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(d.stderr, "redef: %v\n", err)
+		return exitError
+	}
+
+	if d.reportUnusedRules {
+		for _, rule := range unusedRules(roots) {
+			fmt.Fprintf(d.stderr, "redef: rule -%s is enabled but never suppressed a finding\n", rule)
+		}
+	}
+
+	if d.metricsOut != "" {
+		if err = writeMetrics(d.metricsOut, roots); err != nil {
+			fmt.Fprintf(d.stderr, "redef: %v\n", err)
+			return exitError
+		}
+	}
+
+	if d.fix {
+		if err = applyFixes(roots); err != nil {
+			fmt.Fprintf(d.stderr, "redef: %v\n", err)
+			return exitError
+		}
+	}
+
+	for _, graph := range graphs {
+		for act := range graph.All() {
+			if act.Err != nil {
+				code = exitError
+			}
+		}
+	}
+
+	return code
+}
+
+// load loads the packages matching patterns under the build
+// configuration bc, along with everything the analysis needs,
+// reporting any load or type errors.
+func (d *driver) load(patterns []string, bc buildConfig) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+			packages.NeedImports | packages.NeedTypes | packages.NeedTypesSizes |
+			packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedDeps | packages.NeedModule,
+		Tests: d.tests,
+	}
+	if d.env != nil || bc.goos != "" {
+		cfg.Env = append(os.Environ(), d.env...)
+	}
+	if bc.goos != "" {
+		cfg.Env = append(cfg.Env, "GOOS="+bc.goos)
+	}
+	if bc.tags != "" {
+		cfg.BuildFlags = []string{"-tags=" + bc.tags}
+	}
+
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	var nerrs int
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			fmt.Fprintln(d.stderr, err)
+			nerrs++
+		}
+	})
+	if nerrs > 0 {
+		return nil, fmt.Errorf("%d errors during loading", nerrs)
+	}
+
+	return pkgs, nil
+}
+
+// severe holds the categories of findings that are almost certainly
+// bugs, which count as errors even when -error-categories omits them.
+var severe = categorySet{"redef.sync": true}
+
+// hasDiagnostics reports whether any root package has a diagnostic
+// that counts as an error: any at all if fatal is empty, or else one
+// whose category is in fatal or severe.
+func hasDiagnostics(roots []*checker.Action, fatal categorySet) bool {
+	for _, act := range roots {
+		for _, d := range act.Diagnostics {
+			if len(fatal) == 0 || fatal[d.Category] || severe[d.Category] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// categorySet is a set of diagnostic categories, settable as a
+// comma-separated flag. The "redef." prefix of each may be omitted.
+type categorySet map[string]bool
+
+func (cs categorySet) String() string {
+	return strings.Join(slices.Sorted(maps.Keys(cs)), ",")
+}
+
+func (cs *categorySet) Set(value string) error {
+	set := make(categorySet)
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			if !strings.HasPrefix(name, "redef.") {
+				name = "redef." + name
+			}
+			set[name] = true
+		}
+	}
+	*cs = set
+	return nil
+}
+
+// writeSummaryJSON writes the -summary results of the root packages
+// to w as a JSON object mapping each package ID to its summaries.
+func writeSummaryJSON(w io.Writer, roots []*checker.Action) error {
+	summaries := make(map[string][]redef.FuncSummary)
+	for _, act := range roots {
+		if res, ok := act.Result.(*redef.Result); ok && res != nil {
+			summaries[act.Package.ID] = append([]redef.FuncSummary{}, res.Summary...)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(summaries)
+}
+
+// unusedRules returns the rules that were enabled for at least one
+// package but never suppressed a finding in any of them.
+func unusedRules(roots []*checker.Action) (unused []string) {
+	enabled := make(map[string]bool)
+	fired := make(map[string]bool)
+	var order []string
+
+	for _, act := range roots {
+		res, ok := act.Result.(*redef.Result)
+		if !ok || res == nil {
+			continue
+		}
+		for _, rule := range res.Rules {
+			if !enabled[rule] {
+				enabled[rule] = true
+				order = append(order, rule)
+			}
+		}
+		for rule, n := range res.Suppressed {
+			if n > 0 {
+				fired[rule] = true
+			}
+		}
+	}
+
+	for _, rule := range order {
+		if !fired[rule] {
+			unused = append(unused, rule)
+		}
+	}
+
+	return
 }
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/JesseCoretta/go-redef"
+)
+
+// newTestDriver returns a driver which loads packages from the
+// GOPATH-style tree under the repository's testdata directory.
+func newTestDriver(t *testing.T) (*driver, *bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+
+	gopath, err := filepath.Abs(filepath.Join("..", "..", "testdata"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	d := &driver{
+		stdout: stdout,
+		stderr: stderr,
+		env:    []string{"GOPATH=" + gopath, "GO111MODULE=off", "GOWORK=off"},
+	}
+	t.Cleanup(func() {
+		redef.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+			f.Value.Set(f.DefValue)
+		})
+	})
+
+	return d, stdout, stderr
+}
+
+func TestReportUnusedRules(t *testing.T) {
+	d, _, stderr := newTestDriver(t)
+
+	code := d.run([]string{
+		"-report-unused-rules",
+		"-allow-err-shadow",
+		"-allow-loop-shadow",
+		"unusedrules",
+	})
+	if code != exitOK {
+		t.Fatalf("exit code %d, want %d; stderr:\n%s", code, exitOK, stderr)
+	}
+
+	out := stderr.String()
+	if !strings.Contains(out, "rule -allow-loop-shadow is enabled but never suppressed") {
+		t.Errorf("unused allow-loop-shadow not reported; stderr:\n%s", out)
+	}
+	if strings.Contains(out, "allow-err-shadow") {
+		t.Errorf("allow-err-shadow reported as unused; stderr:\n%s", out)
+	}
+}
+
+func TestMetricsOut(t *testing.T) {
+	d, _, stderr := newTestDriver(t)
+	out := filepath.Join(t.TempDir(), "redef.prom")
+
+	code := d.run([]string{"-metrics-out", out, "basic", "typeassert"})
+	if code != exitDiagnostics {
+		t.Fatalf("exit code %d, want %d; stderr:\n%s", code, exitDiagnostics, stderr)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sample := regexp.MustCompile(`^([a-z_]+)\{package="([^"]*)",kind="([^"]*)"\} (\d+)$`)
+	got := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if strings.HasPrefix(line, "# ") {
+			continue
+		}
+		m := sample.FindStringSubmatch(line)
+		if m == nil || m[1] != "redef_shadows_total" {
+			t.Fatalf("malformed sample %q", line)
+		}
+		got[m[2]+"/"+m[3]] = m[4]
+	}
+
+	want := map[string]string{
+		"basic/redef.shadow":             "1",
+		"typeassert/redef.shadow":        "1",
+		"typeassert/redef.shadow.rebind": "1",
+		"typeassert/redef.type-assert":   "1",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got samples %v, want %v", got, want)
+	}
+}
+
+func TestJSONCategories(t *testing.T) {
+	d, stdout, stderr := newTestDriver(t)
+
+	if code := d.run([]string{"-json", "categories"}); code != exitOK {
+		t.Fatalf("exit code %d, want %d; stderr:\n%s", code, exitOK, stderr)
+	}
+
+	var tree map[string]map[string][]struct {
+		Message  string `json:"message"`
+		Category string `json:"category"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &tree); err != nil {
+		t.Fatalf("bad JSON output: %v\n%s", err, stdout)
+	}
+
+	got := make(map[string]string)
+	for _, byAnalyzer := range tree {
+		for _, diag := range byAnalyzer["redef"] {
+			name, _, _ := strings.Cut(strings.TrimPrefix(diag.Message, `variable "`), `"`)
+			got[name] = diag.Category
+		}
+	}
+
+	want := map[string]string{
+		"err": "redef.shadow.err",
+		"n":   "redef.shadow.loop",
+		"j":   "redef.shadow.for-init",
+		"ok":  "redef.shadow.ok",
+		"c":   "redef.shadow.closure",
+		"v":   "redef.shadow.guard",
+		"p":   "redef.shadow",
+		"q":   "redef.shadow.param",
+		"r":   "redef.shadow.receiver",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got categories %v, want %v", got, want)
+	}
+}
+
+func TestFix(t *testing.T) {
+	d, _, stderr := newTestDriver(t)
+
+	gopath := t.TempDir()
+	src := filepath.Join("..", "..", "testdata", "src", "renamefix")
+	dst := filepath.Join(gopath, "src", "renamefix")
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	goldens, err := filepath.Glob(filepath.Join(src, "*.go.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, golden := range goldens {
+		name := strings.TrimSuffix(filepath.Base(golden), ".golden")
+		data, err := os.ReadFile(filepath.Join(src, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(filepath.Join(dst, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.env[0] = "GOPATH=" + gopath
+
+	if code := d.run([]string{"-fix", "renamefix"}); code != exitDiagnostics {
+		t.Fatalf("exit code %d, want %d; stderr:\n%s", code, exitDiagnostics, stderr)
+	}
+
+	for _, golden := range goldens {
+		name := strings.TrimSuffix(filepath.Base(golden), ".golden")
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s after -fix:\n%s\nwant:\n%s", name, got, want)
+		}
+	}
+}
+
+// -fix only renames; the fix turning ":=" into "=" changes what the
+// code does and is left alone.
+func TestFixSkipsReuse(t *testing.T) {
+	d, _, stderr := newTestDriver(t)
+
+	gopath := t.TempDir()
+	dst := filepath.Join(gopath, "src", "suggestreuse")
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "src", "suggestreuse", "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dst, "a.go"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d.env[0] = "GOPATH=" + gopath
+
+	if code := d.run([]string{"-suggest-reuse", "-fix", "suggestreuse"}); code != exitDiagnostics {
+		t.Fatalf("exit code %d, want %d; stderr:\n%s", code, exitDiagnostics, stderr)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dst, "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decl := range []string{"\t\tn := 2 //", "\t\tn, err := f() //"} {
+		if !bytes.Contains(got, []byte(decl)) {
+			t.Errorf("%q rewritten by -fix:\n%s", decl, got)
+		}
+	}
+	// the shadow no reuse fix is offered for is still renamed
+	if !bytes.Contains(got, []byte("m, err2 := f()")) {
+		t.Errorf("rename not applied by -fix:\n%s", got)
+	}
+}
+
+func TestGitHubSuggestions(t *testing.T) {
+	d, stdout, stderr := newTestDriver(t)
+
+	if code := d.run([]string{"-github-suggestions", "renamefix"}); code != exitOK {
+		t.Fatalf("exit code %d, want %d; stderr:\n%s", code, exitOK, stderr)
+	}
+
+	var comments []reviewComment
+	if err := json.Unmarshal(stdout.Bytes(), &comments); err != nil {
+		t.Fatalf("bad JSON output: %v\n%s", err, stdout)
+	}
+
+	var got *reviewComment
+	for i, c := range comments {
+		if strings.HasSuffix(c.Path, "renamefix/a.go") && c.Line == 53 {
+			got = &comments[i]
+		}
+	}
+	if got == nil {
+		t.Fatalf("no comment for renamefix/a.go:53 among %+v", comments)
+	}
+
+	want := "```suggestion\n" +
+		"\t\tc2 := 1 // want `variable \"c\" is redefined`\n" +
+		"\t\treturn func() int { c2++; return c2 }\n" +
+		"```"
+	if got.StartLine != 52 || got.Side != "RIGHT" || !strings.HasSuffix(got.Body, want) {
+		t.Errorf("got comment %+v, want lines 52-53 ending in:\n%s", *got, want)
+	}
+	if !strings.HasPrefix(got.Body, `variable "c" is redefined`) {
+		t.Errorf("comment body %q does not start with the diagnostic", got.Body)
+	}
+}
+
+func TestErrorCategories(t *testing.T) {
+	for _, tc := range []struct {
+		categories string
+		pkg        string
+		want       int
+		warning    string
+	}{
+		{"redef.shadow.err", "categories", exitDiagnostics, `variable "p" is redefined`},
+		{"shadow.loop,named-result", "categories", exitDiagnostics, `variable "p" is redefined`},
+		{"named-result", "categories", exitOK, `variable "p" is redefined`},
+		// sync shadows are errors regardless
+		{"named-result", "syncshadow", exitDiagnostics, `variable "m" is redefined`},
+	} {
+		d, _, stderr := newTestDriver(t)
+
+		code := d.run([]string{"-error-categories", tc.categories, tc.pkg})
+		if code != tc.want {
+			t.Errorf("-error-categories=%s: exit code %d, want %d", tc.categories, code, tc.want)
+		}
+		if !strings.Contains(stderr.String(), tc.warning) {
+			t.Errorf("-error-categories=%s: warnings missing from output:\n%s", tc.categories, stderr)
+		}
+	}
+}
+
+func TestSummaryJSON(t *testing.T) {
+	d, stdout, stderr := newTestDriver(t)
+
+	if code := d.run([]string{"-summary", "-json", "summary"}); code != exitOK {
+		t.Fatalf("exit code %d, want %d; stderr:\n%s", code, exitOK, stderr)
+	}
+
+	var got map[string][]redef.FuncSummary
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("bad JSON output: %v\n%s", err, stdout)
+	}
+
+	summaries := got["summary"]
+	if len(summaries) != 3 {
+		t.Fatalf("got %d summaries, want 3: %+v", len(summaries), got)
+	}
+	foo := summaries[0]
+	if foo.Func != "func (T) Foo" || foo.Posn != "a.go:7:1" || foo.Shadows != 3 ||
+		!maps.Equal(foo.Kinds, map[string]int{"err": 2, "loop": 1}) {
+		t.Errorf("got %+v for the first function", foo)
+	}
+}
+
+func TestBuildConfigs(t *testing.T) {
+	d, _, stderr := newTestDriver(t)
+
+	code := d.run([]string{"-goos", "linux,windows", "-tags", "", "-tags", "foo", "buildtags"})
+	if code != exitDiagnostics {
+		t.Fatalf("exit code %d, want %d; stderr:\n%s", code, exitDiagnostics, stderr)
+	}
+
+	for _, want := range []string{
+		`a.go:8:3: variable "x" is redefined`,
+		`b_windows.go:6:3: variable "y" is redefined`,
+		`c.go:8:3: variable "z" is redefined`,
+	} {
+		if n := strings.Count(stderr.String(), want); n != 1 {
+			t.Errorf("found %q %d times, want once, in:\n%s", want, n, stderr)
+		}
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	d, _, stderr := newTestDriver(t)
+
+	gopath := t.TempDir()
+	dir := filepath.Join(gopath, "src", "baseline")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	d.env[0] = "GOPATH=" + gopath
+	write := func(src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	baseline := filepath.Join(t.TempDir(), "redef.baseline")
+
+	write(`package baseline
+
+func old() {
+	x := 1
+	{
+		x := 2
+		_ = x
+	}
+	_ = x
+}
+`)
+	if code := d.run([]string{"-write-baseline", baseline, "baseline"}); code != exitOK {
+		t.Fatalf("exit code %d, want %d; stderr:\n%s", code, exitOK, stderr)
+	}
+	data, err := os.ReadFile(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "baseline a.go old x +3\n"; !strings.HasSuffix(string(data), want) {
+		t.Fatalf("baseline:\n%s\nwant it to end in %q", data, want)
+	}
+
+	// Shift old down and add a new shadow above it; only the new one
+	// is reported.
+	write(`package baseline
+
+func added() {
+	y := 1
+	{
+		y := 2
+		_ = y
+	}
+	_ = y
+}
+
+func old() {
+	x := 1
+	{
+		x := 2
+		_ = x
+	}
+	_ = x
+}
+`)
+	stderr.Reset()
+	if code := d.run([]string{"-baseline", baseline, "baseline"}); code != exitDiagnostics {
+		t.Fatalf("exit code %d, want %d; stderr:\n%s", code, exitDiagnostics, stderr)
+	}
+	if out := stderr.String(); !strings.Contains(out, `a.go:6:3: variable "y"`) || strings.Contains(out, `"x"`) {
+		t.Errorf("want only the shadow of y reported; stderr:\n%s", out)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"go/token"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis/checker"
+)
+
+// metric is one sample of the redef_shadows_total counter.
+type metric struct {
+	pkg, kind string
+	count     int
+}
+
+// countShadows tallies the diagnostics of the root packages by package
+// path and kind (the diagnostic category). A file belonging to both a
+// package and its test variant is only counted once.
+func countShadows(roots []*checker.Action) []metric {
+	type key struct {
+		pkg, kind string
+	}
+	type site struct {
+		pos     token.Position
+		message string
+	}
+
+	counts := make(map[key]int)
+	seen := make(map[site]bool)
+	for _, act := range roots {
+		if act.Err != nil {
+			continue
+		}
+		for _, d := range act.Diagnostics {
+			s := site{act.Package.Fset.Position(d.Pos), d.Message}
+			if seen[s] {
+				continue
+			}
+			seen[s] = true
+
+			kind := d.Category
+			if kind == "" {
+				kind = "redef.shadow"
+			}
+			counts[key{act.Package.PkgPath, kind}]++
+		}
+	}
+
+	metrics := make([]metric, 0, len(counts))
+	for k, n := range counts {
+		metrics = append(metrics, metric{k.pkg, k.kind, n})
+	}
+	slices.SortFunc(metrics, func(a, b metric) int {
+		return cmp.Or(cmp.Compare(a.pkg, b.pkg), cmp.Compare(a.kind, b.kind))
+	})
+
+	return metrics
+}
+
+// writeMetrics writes the shadow counts of roots to the file name in
+// the Prometheus text exposition format.
+func writeMetrics(name string, roots []*checker.Action) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	fmt.Fprintln(w, "# HELP redef_shadows_total Number of variable shadows reported by redef.")
+	fmt.Fprintln(w, "# TYPE redef_shadows_total counter")
+	for _, m := range countShadows(roots) {
+		fmt.Fprintf(w, "redef_shadows_total{package=\"%s\",kind=\"%s\"} %d\n",
+			escapeLabel(m.pkg), escapeLabel(m.kind), m.count)
+	}
+
+	if err = w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// escapeLabel escapes a label value as the exposition format requires.
+var escapeLabel = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace
@@ -0,0 +1,204 @@
+package redef
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// config is the decoded form of the file named by -config. Since YAML
+// is a superset of JSON, a JSON document saved as .redef.yaml is also
+// accepted, e.g.:
+//
+//	{
+//	  "packages": [
+//	    {"pattern": "example.com/mono/internal/testutil/...", "allow": {"all": true}},
+//	    {"pattern": "example.com/mono/gen/*", "allow": {"allow-err-shadow": true}}
+//	  ]
+//	}
+//
+// Rules are applied in file order, so a later matching rule overrides
+// an earlier one. Flags given explicitly on the command line always
+// win over any rule.
+type config struct {
+	Packages []packageRule `json:"packages"`
+}
+
+// packageRule applies the toggles in Allow to every package whose
+// import path matches Pattern. Pattern uses path.Match syntax, and a
+// trailing "/..." matches the named package and everything below it.
+// The special key "all" sets every allow-* toggle at once.
+type packageRule struct {
+	Pattern string          `json:"pattern"`
+	Allow   map[string]bool `json:"allow"`
+}
+
+var configCache struct {
+	sync.Mutex
+	path string
+	cfg  *config
+	err  error
+}
+
+// loadConfig reads and validates the config file at name. The result
+// is cached, since run is invoked once per package.
+func loadConfig(name string) (*config, error) {
+	configCache.Lock()
+	defer configCache.Unlock()
+
+	if configCache.path != name {
+		configCache.path = name
+		configCache.cfg, configCache.err = readConfig(name)
+	}
+
+	return configCache.cfg, configCache.err
+}
+
+func readConfig(name string) (*config, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("redef: reading config: %w", err)
+	}
+
+	cfg := new(config)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("redef: parsing config %s: %w", name, err)
+	}
+
+	known := new(settings).toggles()
+	for i, rule := range cfg.Packages {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("redef: config %s: packages[%d]: missing pattern", name, i)
+		}
+		if _, err = path.Match(strings.TrimSuffix(rule.Pattern, "/..."), ""); err != nil {
+			return nil, fmt.Errorf("redef: config %s: packages[%d]: bad pattern %q: %w",
+				name, i, rule.Pattern, err)
+		}
+		for key := range rule.Allow {
+			if _, ok := known[key]; !ok && key != "all" && toggleAliases[key] == "" {
+				return nil, fmt.Errorf("redef: config %s: packages[%d]: unknown toggle %q",
+					name, i, key)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// matchPackage reports whether the import path pkg matches pattern.
+func matchPackage(pattern, pkg string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		if ok, _ = path.Match(prefix, pkg); ok {
+			return true
+		}
+		for dir := path.Dir(pkg); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if ok, _ = path.Match(prefix, dir); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	ok, _ := path.Match(pattern, pkg)
+	return ok
+}
+
+// settingsFor returns the settings in effect for the package pkg: the
+// flag values, overlaid by any matching -config rules for toggles that
+// were not set explicitly in fs, and finally by -strict or -lenient,
+// which override both.
+func settingsFor(fs *flag.FlagSet, pkg string) (s settings, err error) {
+	s = flags
+	if s.strict && s.lenient {
+		return s, fmt.Errorf("redef: -strict and -lenient are mutually exclusive")
+	}
+	if configPath != "" {
+		var cfg *config
+		if cfg, err = loadConfig(configPath); err != nil {
+			return
+		}
+		s.applyConfig(cfg, fs, pkg)
+	}
+	s.applyMeta()
+
+	return
+}
+
+// applyConfig overlays s with the rules of cfg matching the package
+// pkg, for toggles that were not set explicitly in fs.
+func (s *settings) applyConfig(cfg *config, fs *flag.FlagSet, pkg string) {
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[cmp.Or(toggleAliases[f.Name], f.Name)] = true
+	})
+
+	toggles := s.toggles()
+	set := func(name string, v bool) {
+		name = cmp.Or(toggleAliases[name], name)
+		if !explicit[name] {
+			*toggles[name] = v
+		}
+	}
+
+	for _, rule := range cfg.Packages {
+		if !matchPackage(rule.Pattern, pkg) {
+			continue
+		}
+		if v, ok := rule.Allow["all"]; ok {
+			for name := range toggles {
+				set(name, v)
+			}
+		}
+		for name, v := range rule.Allow {
+			if name != "all" {
+				set(name, v)
+			}
+		}
+	}
+}
+
+// applyMeta resolves -strict and -lenient. -strict turns off every
+// suppression rule, including -allow-names and its kin, -min-scope-depth
+// and -max-redefs, and turns on every -check-* flag; -lenient turns on
+// every allow-* toggle.
+func (s *settings) applyMeta() {
+	switch {
+	case s.strict:
+		for _, on := range s.toggles() {
+			*on = false
+		}
+		s.allowNames, s.allowNamePrefix, s.allowNameSuffix = nil, "", ""
+		s.minScopeDepth, s.maxRedefs = 0, 0
+		for _, on := range []*bool{
+			&s.checkTypeSwitch,
+			&s.checkSelect,
+			&s.checkVarDecls,
+			&s.checkTypeDecls,
+			&s.checkNamedReturns,
+			&s.checkDeferredReturnShadow,
+			&s.checkErrorChain,
+			&s.checkSyncShadow,
+			&s.checkBranchConsistency,
+			&s.checkClosureParams,
+			&s.checkNonVarOuters,
+			&s.checkFuncOuters,
+			&s.checkPredeclared,
+			&s.checkRedundantLoopCopy,
+		} {
+			*on = true
+		}
+	case s.lenient:
+		for _, on := range s.toggles() {
+			*on = true
+		}
+	}
+}
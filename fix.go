@@ -0,0 +1,258 @@
+package redef
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// renameFix returns a fix renaming the inner variable of f to a fresh
+// name, or nil if no rename is known to be safe. The rename is offered
+// only when every occurrence of the variable can be rewritten without
+// changing what any identifier resolves to; see renameIsSafe for the
+// uses that rule it out. Each variable gets at most one fix, and no two
+// fixes within a top-level function pick the same name, so that all the
+// fixes of a file may be applied together.
+func (c *checker) renameFix(f finding) *analysis.SuggestedFix {
+	info := c.pass.TypesInfo
+	inner, ok := f.inner.(*types.Var)
+	if !ok || f.kind == kindRepeatedDecl || f.kind == kindLoopCopy || f.fn == nil || info.Defs[f.ident] != inner || inner.Pkg() != c.pass.Pkg {
+		// Type switch guards bind a separate object per
+		// clause, which a single rename cannot cover.
+		return nil
+	}
+
+	if c.uses == nil {
+		c.uses = make(map[types.Object][]*ast.Ident)
+		for id, obj := range info.Uses {
+			if v, ok := obj.(*types.Var); ok && !v.IsField() {
+				c.uses[obj] = append(c.uses[obj], id)
+			}
+		}
+		c.renamed = make(map[localName]bool)
+		c.fixed = make(map[*types.Var]bool)
+	}
+	if c.fixed[inner] {
+		// e.g. reported both as a shadow and as a label collision
+		return nil
+	}
+
+	occurrences := append([]*ast.Ident{f.ident}, c.uses[inner]...)
+	if !c.renameIsSafe(inner, f.fn, occurrences) {
+		return nil
+	}
+
+	// Names are reserved per top-level function rather than per
+	// closure, as a closure's new name could otherwise be picked for
+	// a variable of the enclosing function as well.
+	fn := c.topLevelBody(f.fn)
+	name := c.freshName(inner, fn, occurrences)
+	if name == "" {
+		return nil
+	}
+	c.renamed[localName{fn, name}] = true
+	c.fixed[inner] = true
+
+	edits := make([]analysis.TextEdit, 0, len(occurrences))
+	for _, id := range occurrences {
+		edits = append(edits, analysis.TextEdit{
+			Pos:     id.Pos(),
+			End:     id.End(),
+			NewText: []byte(name),
+		})
+	}
+
+	return &analysis.SuggestedFix{
+		Message:   fmt.Sprintf("Rename inner %q to %q", inner.Name(), name),
+		TextEdits: edits,
+	}
+}
+
+// reuseFix returns a fix turning the := of as into =, so that it
+// assigns to the variables it would otherwise shadow, or nil if a
+// fix for as has already been offered.
+func (c *checker) reuseFix(as *ast.AssignStmt) *analysis.SuggestedFix {
+	if c.reused == nil {
+		c.reused = make(map[*ast.AssignStmt]bool)
+	}
+	if c.reused[as] {
+		return nil
+	}
+	c.reused[as] = true
+
+	return &analysis.SuggestedFix{
+		Message: `Assign with "=" instead of ":="`,
+		TextEdits: []analysis.TextEdit{{
+			Pos:     as.TokPos,
+			End:     as.TokPos + token.Pos(len(token.DEFINE.String())),
+			NewText: []byte(token.ASSIGN.String()),
+		}},
+	}
+}
+
+// removeCopyFix returns a fix deleting the line holding the redundant
+// loop variable copy as, or nil unless as is alone on its line, save
+// for a trailing comment.
+func (c *checker) removeCopyFix(as *ast.AssignStmt) *analysis.SuggestedFix {
+	tf := c.pass.Fset.File(as.Pos())
+	if tf == nil || c.pass.ReadFile == nil {
+		return nil
+	}
+	src, err := c.pass.ReadFile(tf.Name())
+	if err != nil || len(src) != tf.Size() {
+		return nil
+	}
+
+	line := tf.Line(as.Pos())
+	start, end := tf.LineStart(line), tf.Pos(tf.Size())
+	if line < tf.LineCount() {
+		end = tf.LineStart(line + 1)
+	}
+	before := src[tf.Offset(start):tf.Offset(as.Pos())]
+	after := strings.TrimSpace(string(src[tf.Offset(as.End()):tf.Offset(end)]))
+	if strings.TrimSpace(string(before)) != "" || after != "" && !strings.HasPrefix(after, "//") {
+		return nil
+	}
+
+	return &analysis.SuggestedFix{
+		Message:   fmt.Sprintf("Remove the redundant copy of %q", as.Lhs[0].(*ast.Ident).Name),
+		TextEdits: []analysis.TextEdit{{Pos: start, End: end}},
+	}
+}
+
+// freshName returns the first of name2, name3, ... that resolves to
+// nothing at any occurrence of inner, and is not declared anywhere
+// in the scope of inner either (which would turn the := into a
+// redeclaration), or an empty string after a reasonable number of
+// attempts.
+func (c *checker) freshName(inner *types.Var, fn *ast.BlockStmt, occurrences []*ast.Ident) string {
+	pkgScope := c.pass.Pkg.Scope()
+	base := strings.TrimRight(inner.Name(), "0123456789")
+
+next:
+	for i := 2; i < 100; i++ {
+		name := base + strconv.Itoa(i)
+		if name == inner.Name() || c.renamed[localName{fn, name}] || inner.Parent().Lookup(name) != nil {
+			continue
+		}
+		for _, id := range occurrences {
+			scope := pkgScope.Innermost(id.Pos())
+			if scope == nil {
+				return ""
+			}
+			if _, obj := scope.LookupParent(name, id.Pos()); obj != nil {
+				continue next
+			}
+		}
+		return name
+	}
+
+	return ""
+}
+
+// renameIsSafe reports whether inner, declared in the function body fn,
+// may be renamed. A rename is considered unsafe when the variable is
+// handed to the reflect package, named by a //go:linkname directive,
+// or captured by a closure that an exported function returns, since
+// the name may then be observed beyond the reach of the type checker.
+func (c *checker) renameIsSafe(inner *types.Var, fn *ast.BlockStmt, occurrences []*ast.Ident) bool {
+	info := c.pass.TypesInfo
+	uses := func(n ast.Node) (found bool) {
+		ast.Inspect(n, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && info.Uses[id] == inner {
+				found = true
+			}
+			return !found
+		})
+		return
+	}
+
+	file := c.fileOf(inner.Pos())
+	if file == nil {
+		return false
+	}
+	for _, id := range occurrences {
+		if c.fileOf(id.Pos()) != file {
+			return false
+		}
+	}
+
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if args, ok := strings.CutPrefix(comment.Text, "//go:linkname "); ok {
+				for _, arg := range strings.Fields(args) {
+					if arg == inner.Name() || strings.HasSuffix(arg, "."+inner.Name()) {
+						return false
+					}
+				}
+			}
+		}
+	}
+
+	safe := true
+	ast.Inspect(fn, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if callee := typeutil.Callee(info, call); callee != nil &&
+				callee.Pkg() != nil && callee.Pkg().Path() == "reflect" {
+				for _, arg := range call.Args {
+					safe = safe && !uses(arg)
+				}
+			}
+		}
+		return safe
+	})
+	if !safe {
+		return false
+	}
+
+	for _, decl := range file.Decls {
+		fd, ok := decl.(*ast.FuncDecl)
+		if !ok || fd.Body == nil || !fd.Name.IsExported() ||
+			inner.Pos() < fd.Body.Pos() || inner.Pos() >= fd.Body.End() {
+			continue
+		}
+		ast.Inspect(fd.Body, func(n ast.Node) bool {
+			if ret, ok := n.(*ast.ReturnStmt); ok {
+				for _, res := range ret.Results {
+					if lit, ok := ast.Unparen(res).(*ast.FuncLit); ok && uses(lit) {
+						safe = false
+					}
+				}
+			}
+			return safe
+		})
+	}
+
+	return safe
+}
+
+// topLevelBody returns the body of the function declaration enclosing
+// the function body fn, or fn itself if it belongs to a function
+// literal outside any declaration.
+func (c *checker) topLevelBody(fn *ast.BlockStmt) *ast.BlockStmt {
+	if file := c.fileOf(fn.Pos()); file != nil {
+		for _, decl := range file.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok && fd.Body != nil &&
+				fd.Body.Pos() <= fn.Pos() && fn.End() <= fd.Body.End() {
+				return fd.Body
+			}
+		}
+	}
+	return fn
+}
+
+// fileOf returns the file of the package containing pos, or nil.
+func (c *checker) fileOf(pos token.Pos) *ast.File {
+	for _, f := range c.pass.Files {
+		if f.FileStart <= pos && pos <= f.FileEnd {
+			return f
+		}
+	}
+	return nil
+}
@@ -1,10 +1,26 @@
 package redef
 
 import (
+	"fmt"
 	"go/ast"
+	"go/build"
 	"go/token"
 	"go/types"
+	"go/version"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
@@ -17,198 +33,2822 @@ var Analyzer = &analysis.Analyzer{
 	Requires: []*analysis.Analyzer{
 		inspect.Analyzer,
 	},
-	Run: run,
+	Run:        run,
+	ResultType: reflect.TypeOf((*Result)(nil)),
 }
 
 func run(pass *analysis.Pass) (interface{}, error) {
-	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
-	parent := buildParentMap(insp)
+	c, err := check(pass, pass.ResultOf[inspect.Analyzer].(*inspector.Inspector))
+	if err != nil {
+		return nil, err
+	}
+	c.flush()
 
-	insp.Preorder([]ast.Node{(*ast.AssignStmt)(nil)}, func(n ast.Node) {
-		if skipFile(pass, n) {
-			return
+	return &Result{
+		Rules:      c.enabledRules(),
+		Suppressed: c.suppressed,
+		Summary:    c.summaries,
+		Shadows:    c.shadows,
+	}, nil
+}
+
+// check walks every short variable declaration of the package and
+// returns the checker holding the findings, ready to be flushed.
+func check(pass *analysis.Pass, insp *inspector.Inspector) (*checker, error) {
+	s, err := settingsFor(&pass.Analyzer.Flags, pass.Pkg.Path())
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{
+		pass:       pass,
+		suppressed: make(map[string]int),
+		settings:   s,
+	}
+	if baselinePath != "" {
+		if c.baseline, err = loadBaseline(baselinePath); err != nil {
+			return nil, err
+		}
+	}
+	if c.skipCgo {
+		c.cgoFiles = cgoFiles(pass)
+	}
+	if c.ignoreGenerated {
+		c.generatedFiles = generatedFiles(pass)
+	}
+	if !c.includeVendor {
+		c.thirdPartyFiles = thirdPartyFiles(pass)
+	}
+	if c.ssa {
+		c.ssaSites = ssaSites(pass)
+	}
+
+	workers := c.concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers == 1 || len(pass.Files) < 2 {
+		c.walk(insp)
+		return c, nil
+	}
+
+	// Check each file with a checker of its own, sharing what the
+	// checkers would otherwise each compute for the whole package.
+	// Nothing is reported until the findings are merged and flushed.
+	c.directives = directives(pass)
+	if c.minScopeDepth > 0 {
+		c.scopeNodes = scopeNodes(pass.TypesInfo)
+	}
+	parts := make([]*checker, len(pass.Files))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, f := range pass.Files {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			part := &checker{
+				pass:            pass,
+				suppressed:      make(map[string]int),
+				cgoFiles:        c.cgoFiles,
+				generatedFiles:  c.generatedFiles,
+				thirdPartyFiles: c.thirdPartyFiles,
+				directives:      c.directives,
+				scopeNodes:      c.scopeNodes,
+				ssaSites:        c.ssaSites,
+				settings:        c.settings,
+			}
+			part.walk(inspector.New([]*ast.File{f}))
+			parts[i] = part
+		})
+	}
+	wg.Wait()
+
+	for _, part := range parts {
+		c.findings = append(c.findings, part.findings...)
+		c.explanations = append(c.explanations, part.explanations...)
+		for rule, n := range part.suppressed {
+			c.suppressed[rule] += n
+		}
+		if part.reuse != nil {
+			if c.reuse == nil {
+				c.reuse = make(map[*ast.Ident]*ast.AssignStmt)
+			}
+			maps.Copy(c.reuse, part.reuse)
+		}
+	}
+
+	return c, nil
+}
+
+// walk checks every short variable declaration of the files of insp,
+// along with range keys and values and, with -check-var-decls and
+// -check-type-decls, local var and type declarations.
+func (c *checker) walk(insp *inspector.Inspector) {
+	filter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+		(*ast.RangeStmt)(nil),
+		(*ast.ValueSpec)(nil),
+		(*ast.TypeSpec)(nil),
+		(*ast.FuncLit)(nil),
+	}
+	insp.WithStack(filter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
 		}
 		as, ok := n.(*ast.AssignStmt)
-		if !ok || as.Tok != token.DEFINE {
-			return
+		switch n := n.(type) {
+		case *ast.RangeStmt:
+			as, ok = rangeAssign(n), n.Tok == token.DEFINE
+			stack = append(stack[:len(stack):len(stack)], as)
+		case *ast.ValueSpec:
+			as, ok = varAssign(n), c.checkVarDecls && isLocalDecl(stack, token.VAR)
+			stack = append(stack[:len(stack):len(stack)], as)
+		case *ast.TypeSpec:
+			as, ok = typeAssign(n), c.checkTypeDecls && isLocalDecl(stack, token.TYPE)
+			stack = append(stack[:len(stack):len(stack)], as)
+		case *ast.FuncLit:
+			as = paramAssign(n)
+			ok = c.checkClosureParams && len(as.Lhs) > 0
+			stack = append(stack[:len(stack):len(stack)], as)
+		}
+		if !ok || as.Tok != token.DEFINE || c.isCgoFile(n) || c.isThirdParty(n) || c.ignored(as) {
+			return true
+		}
+		c.parent = stack
+		if c.excluded() {
+			return true
+		}
+		if ts, ok := c.parent.of(as).(*ast.TypeSwitchStmt); ok && ts.Assign == as {
+			if c.checkTypeSwitch {
+				c.processTypeSwitch(ts, c.skipFile(n))
+			}
+			return true
 		}
-		processAssign(pass, as, parent)
+		if cc, ok := c.parent.of(as).(*ast.CommClause); ok && cc.Comm == as && !c.checkSelect {
+			// case v := <-ch binds v in the clause's own scope
+			return true
+		}
+		switch n.(type) {
+		case *ast.TypeSpec, *ast.FuncLit:
+			for _, lhs := range as.Lhs {
+				if id := lhs.(*ast.Ident); id.Name != "_" && c.pass.TypesInfo.Defs[id] != nil {
+					c.checkIdent(id, c.pass.TypesInfo.Defs[id], as, c.skipFile(n))
+				}
+			}
+			return true
+		}
+		c.processAssign(as, c.skipFile(n))
+		return true
 	})
+	c.reportBlockDecls()
+}
 
-	return nil, nil
+// varAssign returns the equivalent of the var spec vs as a :=, as in
+// "x, y := 1, 2" for "var x, y = 1, 2", so that it is checked like any
+// other. Without values, the type stands in for them, keeping the
+// assignment's extent within vs. As with rangeAssign, the caller must
+// push the assignment onto the stack.
+func varAssign(vs *ast.ValueSpec) *ast.AssignStmt {
+	as := &ast.AssignStmt{
+		Tok: token.DEFINE,
+		Rhs: vs.Values,
+	}
+	for _, name := range vs.Names {
+		as.Lhs = append(as.Lhs, name)
+	}
+	if len(as.Rhs) == 0 {
+		as.Rhs = []ast.Expr{vs.Type}
+	}
+	return as
+}
+
+// typeAssign returns the equivalent of the type spec ts as a :=, as in
+// "T := struct{}" for "type T struct{}", so that the filters applying
+// to any statement apply to it too. As with rangeAssign, the caller
+// must push the assignment onto the stack.
+func typeAssign(ts *ast.TypeSpec) *ast.AssignStmt {
+	return &ast.AssignStmt{
+		Lhs: []ast.Expr{ts.Name},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{ts.Type},
+	}
+}
+
+// paramAssign returns the equivalent of the parameters of lit as a :=,
+// as in "a, b := func(a, b int)", so that the filters applying to any
+// statement apply to them too. As with rangeAssign, the caller must
+// push the assignment onto the stack, where it is the only assignment
+// whose parent is a function literal.
+func paramAssign(lit *ast.FuncLit) *ast.AssignStmt {
+	as := &ast.AssignStmt{
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{lit.Type},
+	}
+	for _, field := range lit.Type.Params.List {
+		for _, name := range field.Names {
+			as.Lhs = append(as.Lhs, name)
+		}
+	}
+	return as
+}
+
+// isLocalDecl reports whether the spec atop stack is declared within a
+// function, by a declaration statement of kind tok (VAR or TYPE).
+func isLocalDecl(stack []ast.Node, tok token.Token) bool {
+	if len(stack) < 3 {
+		return false
+	}
+	decl, ok := stack[len(stack)-2].(*ast.GenDecl)
+	if !ok || decl.Tok != tok {
+		return false
+	}
+	_, ok = stack[len(stack)-3].(*ast.DeclStmt)
+	return ok
+}
+
+// rangeAssign returns the implicit assignment of a range statement's
+// key and value, as in "k, v := range m", so that they are checked like
+// any other :=. The assignment is a child of rng, but not part of the
+// syntax tree; the caller must push it onto the stack.
+func rangeAssign(rng *ast.RangeStmt) *ast.AssignStmt {
+	as := &ast.AssignStmt{
+		TokPos: rng.TokPos,
+		Tok:    rng.Tok,
+		Rhs:    []ast.Expr{rng.X},
+	}
+	for _, e := range []ast.Expr{rng.Key, rng.Value} {
+		if e != nil {
+			as.Lhs = append(as.Lhs, e)
+		}
+	}
+	return as
+}
+
+// Result is the result of the Analyzer for a single package. It lets
+// drivers aggregate information across packages, which individual
+// passes cannot do.
+type Result struct {
+	// Rules lists the suppression rules (allow-* flag names)
+	// that were enabled for the package.
+	Rules []string
+
+	// Suppressed counts, per rule, the shadows it suppressed.
+	Suppressed map[string]int
+
+	// Summary holds the per-function summaries reported in place of
+	// the individual findings with -summary, in source order.
+	Summary []FuncSummary
+
+	// Shadows lists the findings of the package in source order,
+	// whether they were reported individually, by cluster or by
+	// function.
+	Shadows []ShadowInfo
+}
+
+// ShadowInfo describes a single finding, for analyzers that require
+// Analyzer and read its Result.
+type ShadowInfo struct {
+	// Pos is the position of the inner identifier, named Name.
+	Pos  token.Pos
+	Name string
+
+	// Outer is the position of the object the identifier relates
+	// to, usually the shadowed variable. It is token.NoPos for a
+	// builtin.
+	Outer token.Pos
+
+	// Category is the stable code of the finding, such as
+	// "redef.shadow.err".
+	Category string
+
+	// Fingerprint identifies the finding in a -baseline file.
+	Fingerprint string
+}
+
+// checker carries the state of a single run over one package.
+type checker struct {
+	pass            *analysis.Pass
+	parent          ancestors
+	findings        []finding
+	summaries       []FuncSummary
+	shadows         []ShadowInfo
+	suppressed      map[string]int
+	cgoFiles        map[*token.File]bool
+	generatedFiles  map[*token.File]bool
+	thirdPartyFiles map[*token.File]bool
+	baseline        map[string]bool                    // fingerprints read from -baseline
+	renamed         map[localName]bool                 // names taken by rename fixes
+	fixed           map[*types.Var]bool                // variables renamed by a fix
+	reuse           map[*ast.Ident]*ast.AssignStmt     // for -suggest-reuse
+	reused          map[*ast.AssignStmt]bool           // statements turned into "=" by a fix
+	blockDecls      map[localName][]*ast.Ident         // for -warn-repeated-block-decl
+	treadmills      map[treadmill]map[*ast.IfStmt]bool // for -warn-err-treadmill
+	uses            map[types.Object][]*ast.Ident      // built on demand by renameFix
+	scopeNodes      map[*types.Scope]ast.Node          // built on demand by scopeDepth
+	ssaSites        map[*ast.Ident]ssaSite             // with -ssa
+	directives      map[*token.File]map[int]bool       // built on demand by ignored
+	explanations    []explanation                      // for -explain
+	settings
+}
+
+// localName is a name within the function with the given body.
+type localName struct {
+	fn   *ast.BlockStmt
+	name string
+}
+
+// treadmill identifies the if statements of one block that shadow the
+// same outer error variable in their init, for -warn-err-treadmill.
+type treadmill struct {
+	block *ast.BlockStmt
+	outer types.Object
+}
+
+// Kinds of finding, carried as the Category of each diagnostic. These
+// codes appear in the -json output and are meant to be stable, so tools
+// may match on them.
+//
+// A plain shadow is refined into one of the redef.shadow.* codes when it
+// matches the shape of a shadow that an allow-* rule could have
+// suppressed; the code thus tells why such a rule might be relevant,
+// whether or not it is enabled.
+const (
+	kindShadow         = "redef.shadow"
+	kindErrShadow      = "redef.shadow.err"
+	kindOkShadow       = "redef.shadow.ok"
+	kindLoopShadow     = "redef.shadow.loop"
+	kindForInit        = "redef.shadow.for-init"
+	kindGuardShadow    = "redef.shadow.guard"
+	kindTableShadow    = "redef.shadow.table"
+	kindParamShadow    = "redef.shadow.param"
+	kindRecvShadow     = "redef.shadow.receiver"
+	kindClosureShadow  = "redef.shadow.closure"
+	kindDeferShadow    = "redef.shadow.defer"
+	kindClosureParam   = "redef.shadow.closure-param"
+	kindElseIfChain    = "redef.shadow.else-if"
+	kindRebind         = "redef.shadow.rebind"
+	kindChanShadow     = "redef.shadow.chan"
+	kindLostWrite      = "redef.lost-write"
+	kindDroppedError   = "redef.dropped-error"
+	kindSync           = "redef.sync"
+	kindGoShadow       = "redef.shadow.go"
+	kindNonVarShadow   = "redef.shadow.nonvar"
+	kindImportShadow   = "redef.shadow.import"
+	kindConstShadow    = "redef.shadow.const"
+	kindTypeShadow     = "redef.shadow.type"
+	kindFuncShadow     = "redef.shadow.func"
+	kindPredeclared    = "redef.shadow.predeclared"
+	kindTestingParam   = "redef.testing-param"
+	kindDeferredResult = "redef.deferred-result"
+	kindDeferredReturn = "redef.deferred-return"
+	kindNamedResult    = "redef.named-result"
+	kindFuncVar        = "redef.func-var"
+	kindTypeAssert     = "redef.type-assert"
+	kindLabelName      = "redef.label-name"
+	kindPool           = "redef.pool"
+	kindContext        = "redef.context"
+	kindBranchMismatch = "redef.branch-mismatch"
+	kindGoroutine      = "redef.goroutine"
+	kindRepeatedDecl   = "redef.repeated-decl"
+	kindTypeChange     = "redef.type-change"
+	kindLoopCopy       = "redef.loop-copy"
+	kindErrTreadmill   = "redef.err-treadmill"
+	kindErrorChain     = "redef.error-chain"
+	kindCluster        = "redef.cluster"
+	kindSummary        = "redef.summary"
+)
+
+// finding is a single shadowing site awaiting emission by flush.
+type finding struct {
+	kind    string
+	ident   *ast.Ident
+	inner   types.Object
+	outer   types.Object
+	fn      *ast.BlockStmt // body of the enclosing function
+	message string
+	related []analysis.RelatedInformation // besides the outer
+	copy    *ast.AssignStmt               // redundant loop copy, for removal
+}
+
+// report records a shadowing site. Nothing reaches the pass until flush.
+func (c *checker) report(kind string, ident *ast.Ident, inner, outer types.Object, format string, args ...any) {
+	c.reportIn(findFuncBody(c.parent[len(c.parent)-1], c.parent), kind, ident, inner, outer, format, args...)
+}
+
+// reportIn is report for findings made outside the traversal, whose
+// enclosing function body fn the stack no longer tells.
+func (c *checker) reportIn(fn *ast.BlockStmt, kind string, ident *ast.Ident, inner, outer types.Object, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if c.messageTemplate.Template != nil {
+		message = c.messageTemplate.render(messageData{
+			Inner:    ident.Name,
+			Outer:    outer.Name(),
+			OuterPos: c.shortPos(outer.Pos()),
+			Category: kind,
+			Message:  message,
+		})
+	}
+
+	c.findings = append(c.findings, finding{
+		kind:    kind,
+		ident:   ident,
+		inner:   inner,
+		outer:   outer,
+		fn:      fn,
+		message: message,
+	})
+}
+
+// shortPos formats pos as file:line:column, with the directory
+// stripped from the file name.
+func (c *checker) shortPos(pos token.Pos) string {
+	p := c.pass.Fset.Position(pos)
+	if !p.IsValid() {
+		return "an unknown position"
+	}
+	return fmt.Sprintf("%s:%d:%d", filepath.Base(p.Filename), p.Line, p.Column)
+}
+
+// flush emits the recorded findings, either one diagnostic per site or,
+// with -cluster-by-outer, one diagnostic per outer variable carrying a
+// related entry for each site that shadows it.
+func (c *checker) flush() {
+	c.writeExplanations()
+	findings := c.sorted(c.overThreshold())
+	for _, f := range findings {
+		c.shadows = append(c.shadows, ShadowInfo{
+			Pos:         f.ident.Pos(),
+			Name:        f.ident.Name,
+			Outer:       f.outer.Pos(),
+			Category:    f.kind,
+			Fingerprint: c.fingerprint(f),
+		})
+	}
+	if c.summary {
+		c.flushSummary(findings)
+		return
+	}
+	if !c.clusterByOuter {
+		for _, f := range findings {
+			d := analysis.Diagnostic{
+				Pos:      f.ident.Pos(),
+				End:      f.ident.End(),
+				Category: f.kind,
+				Message:  f.message,
+			}
+			if f.outer.Pos().IsValid() {
+				related := "outer %q declared here"
+				if f.kind == kindRepeatedDecl {
+					related = "earlier %q declared here"
+				}
+				d.Related = []analysis.RelatedInformation{{
+					Pos:     f.outer.Pos(),
+					End:     f.outer.Pos() + token.Pos(len(f.outer.Name())),
+					Message: fmt.Sprintf(related, f.outer.Name()),
+				}}
+			}
+			d.Related = append(d.Related, f.related...)
+			var fix *analysis.SuggestedFix
+			if f.copy != nil {
+				fix = c.removeCopyFix(f.copy)
+			} else if as := c.reuse[f.ident]; as != nil {
+				// Renaming one variable of the statement
+				// would clash with turning it into "=".
+				fix = c.reuseFix(as)
+			} else {
+				fix = c.renameFix(f)
+			}
+			if fix != nil {
+				d.SuggestedFixes = []analysis.SuggestedFix{*fix}
+			}
+			c.pass.Report(d)
+		}
+		return
+	}
+
+	var outers []types.Object
+	clusters := make(map[types.Object][]finding)
+	for _, f := range findings {
+		if _, ok := clusters[f.outer]; !ok {
+			outers = append(outers, f.outer)
+		}
+		clusters[f.outer] = append(clusters[f.outer], f)
+	}
+
+	sort.SliceStable(outers, func(i, j int) bool {
+		return c.before(outers[i].Pos(), outers[j].Pos())
+	})
+
+	for _, outer := range outers {
+		sites := clusters[outer]
+		times := "times"
+		if len(sites) == 1 {
+			times = "time"
+		}
+		pos := outer.Pos()
+		if !pos.IsValid() {
+			// a builtin, with -check-nonvar-outers
+			pos = sites[0].ident.Pos()
+		}
+		d := analysis.Diagnostic{
+			Pos:      pos,
+			Category: kindCluster,
+			Message: fmt.Sprintf("variable %q is redefined %d %s by inner declarations that shadow it",
+				outer.Name(), len(sites), times),
+		}
+		for _, f := range sites {
+			d.Related = append(d.Related, analysis.RelatedInformation{
+				Pos:     f.ident.Pos(),
+				End:     f.ident.End(),
+				Message: f.message,
+			})
+		}
+		c.pass.Report(d)
+	}
+}
+
+// sorted sorts findings by position, so that the output does not depend
+// on the order in which they were found, nor on the order in which the
+// files were added to the file set.
+func (c *checker) sorted(findings []finding) []finding {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return c.before(findings[i].ident.Pos(), findings[j].ident.Pos())
+	})
+	return findings
+}
+
+// before reports whether a comes before b by file name, line and
+// column.
+func (c *checker) before(a, b token.Pos) bool {
+	pa, pb := c.pass.Fset.Position(a), c.pass.Fset.Position(b)
+	if pa.Filename != pb.Filename {
+		return pa.Filename < pb.Filename
+	}
+	return pa.Offset < pb.Offset
+}
+
+// overThreshold returns the findings left once those recorded in the
+// -baseline are dropped and, with -max-redefs N, the first N plain
+// shadows of each outer variable within a function are tolerated.
+// Hazards are never tolerated, but may be in the baseline.
+func (c *checker) overThreshold() []finding {
+	if c.maxRedefs <= 0 && c.baseline == nil {
+		return c.findings
+	}
+
+	type key struct {
+		fn    *ast.BlockStmt
+		outer types.Object
+	}
+	seen := make(map[key]int)
+	var kept []finding
+	for _, f := range c.findings {
+		if c.baseline[c.fingerprint(f)] {
+			c.suppressed["baseline"]++
+			continue
+		}
+		if c.maxRedefs > 0 && strings.HasPrefix(f.kind, kindShadow) {
+			k := key{f.fn, f.outer}
+			if seen[k]++; seen[k] <= c.maxRedefs {
+				c.suppressed["max-redefs"]++
+				continue
+			}
+		}
+		kept = append(kept, f)
+	}
+
+	return kept
+}
+
+// ancestors is the traversal stack supplied by inspector.WithStack,
+// from the enclosing *ast.File down to the node being visited. Every
+// parent lookup made while checking a node concerns one of its own
+// ancestors, so the stack stands in for a package-wide parent map and
+// spares a second walk over every file.
+type ancestors []ast.Node
+
+// of returns the parent of n, which must be on the stack, or nil.
+func (a ancestors) of(n ast.Node) ast.Node {
+	for i := len(a) - 1; i > 0; i-- {
+		if a[i] == n {
+			return a[i-1]
+		}
+	}
+	return nil
+}
+
+func (c *checker) skipFile(n ast.Node) (skip bool) {
+	if c.ignoreTests {
+		pos := c.pass.Fset.Position(n.Pos())
+		skip = strings.HasSuffix(pos.Filename, "_test.go")
+	}
+	if c.ignoreGenerated && !skip {
+		skip = c.generatedFiles[c.pass.Fset.File(n.Pos())]
+	}
+
+	return
+}
+
+// ignored reports whether as is exempted by an inline directive. A
+// directive is a //redef:ignore or //nolint:redef comment (a bare
+// //nolint or a //nolint list naming redef also counts) and applies to
+// the line it is on and to the line below it, so it may either trail
+// the := statement or sit on a line of its own just above it.
+func (c *checker) ignored(as *ast.AssignStmt) bool {
+	tf := c.pass.Fset.File(as.Pos())
+	if tf == nil {
+		return false
+	}
+
+	if c.directives == nil {
+		c.directives = directives(c.pass)
+	}
+
+	lines := c.directives[tf]
+	line := tf.Line(as.Pos())
+	return lines[line] || lines[line-1]
+}
+
+// directives returns, per file of pass, the lines holding an ignore
+// directive.
+func directives(pass *analysis.Pass) map[*token.File]map[int]bool {
+	files := make(map[*token.File]map[int]bool)
+	for _, f := range pass.Files {
+		lines := make(map[int]bool)
+		for _, group := range f.Comments {
+			for _, comment := range group.List {
+				if isIgnoreDirective(comment.Text) {
+					lines[pass.Fset.Position(comment.Slash).Line] = true
+				}
+			}
+		}
+		files[pass.Fset.File(f.FileStart)] = lines
+	}
+	return files
+}
+
+// isIgnoreDirective reports whether the comment text suppresses redef.
+func isIgnoreDirective(text string) bool {
+	if rest, ok := strings.CutPrefix(text, "//redef:ignore"); ok {
+		return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+	}
+
+	rest, ok := strings.CutPrefix(text, "//nolint")
+	if !ok {
+		return false
+	}
+	linters, _, _ := strings.Cut(rest, " ")
+	if linters == "" {
+		return true
+	}
+	list, ok := strings.CutPrefix(linters, ":")
+	if !ok {
+		return false
+	}
+	for _, name := range strings.Split(list, ",") {
+		if name == "redef" {
+			return true
+		}
+	}
+	return false
+}
+
+// cgoPrefixes are the prefixes cgo uses for the identifiers it
+// synthesizes when rewriting a file that imports "C".
+var cgoPrefixes = []string{"_Cgo_", "_cgo_", "_Cfunc_", "_Ctype_", "_Cvar_", "_Cmacro_"}
+
+func isCgoName(name string) bool {
+	for _, prefix := range cgoPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cgoFiles returns the files of pass which either import "C" or were
+// generated by cgo itself.
+func cgoFiles(pass *analysis.Pass) map[*token.File]bool {
+	files := make(map[*token.File]bool)
+	for _, f := range pass.Files {
+		tf := pass.Fset.File(f.Pos())
+		if tf == nil {
+			continue
+		}
+		if isCgoName(filepath.Base(tf.Name())) {
+			files[tf] = true
+			continue
+		}
+		for _, imp := range f.Imports {
+			if imp.Path.Value == `"C"` {
+				files[tf] = true
+				break
+			}
+		}
+	}
+	return files
+}
+
+// generatedFiles returns the files of pass which carry the standard
+// "// Code generated ... DO NOT EDIT." header.
+func generatedFiles(pass *analysis.Pass) map[*token.File]bool {
+	files := make(map[*token.File]bool)
+	for _, f := range pass.Files {
+		if ast.IsGenerated(f) {
+			files[pass.Fset.File(f.Pos())] = true
+		}
+	}
+	return files
+}
+
+// thirdPartyFiles returns the files of pass which lie in a vendor
+// directory or in the module cache, where findings cannot be acted on.
+func thirdPartyFiles(pass *analysis.Pass) map[*token.File]bool {
+	modCache := os.Getenv("GOMODCACHE")
+	if modCache == "" && build.Default.GOPATH != "" {
+		modCache = filepath.Join(filepath.SplitList(build.Default.GOPATH)[0], "pkg", "mod")
+	}
+
+	files := make(map[*token.File]bool)
+	for _, f := range pass.Files {
+		tf := pass.Fset.File(f.Pos())
+		if tf == nil {
+			continue
+		}
+		name := filepath.ToSlash(tf.Name())
+		if strings.Contains(name, "/vendor/") ||
+			modCache != "" && strings.HasPrefix(name, filepath.ToSlash(modCache)+"/") {
+			files[tf] = true
+		}
+	}
+	return files
+}
+
+// isThirdParty reports whether n belongs to a file recorded by
+// thirdPartyFiles.
+func (c *checker) isThirdParty(n ast.Node) bool {
+	return c.thirdPartyFiles[c.pass.Fset.File(n.Pos())]
+}
+
+// isCgoFile reports whether n belongs to a file recorded by cgoFiles.
+func (c *checker) isCgoFile(n ast.Node) bool {
+	return c.cgoFiles[c.pass.Fset.File(n.Pos())]
+}
+
+func (c *checker) processAssign(as *ast.AssignStmt, skip bool) {
+	for _, lhs := range as.Lhs {
+		// Only an identifier can be declared by :=. Anything else,
+		// such as b.F or (a), is a type error which go/types
+		// records as uses, never as a definition.
+		ident, ok := lhs.(*ast.Ident)
+		if !ok || ident.Name == "_" || c.skipCgo && isCgoName(ident.Name) {
+			continue
+		}
+
+		// In a, b := ..., a name already declared in the same
+		// scope is merely assigned to; Defs has no entry for it.
+		obj := c.pass.TypesInfo.Defs[ident]
+		if obj == nil {
+			continue
+		}
+		if c.warnLabelNameCollision && !skip {
+			c.checkLabelCollision(ident, as)
+		}
+		if c.warnRepeatedBlockDecl && !skip {
+			c.recordBlockDecl(ident, obj, as)
+		}
+		c.checkIdent(ident, obj, as, skip)
+	}
+}
+
+// recordBlockDecl notes ident if as declares it directly in a nested
+// block of a function without shadowing anything, for reportBlockDecls.
+func (c *checker) recordBlockDecl(ident *ast.Ident, inner types.Object, as *ast.AssignStmt) {
+	block, ok := c.parent.of(as).(*ast.BlockStmt)
+	fn := findFuncBody(as, c.parent)
+	if !ok || fn == nil || block == fn || c.findOuter(ident, inner) != nil {
+		return
+	}
+
+	if c.blockDecls == nil {
+		c.blockDecls = make(map[localName][]*ast.Ident)
+	}
+	key := localName{fn, ident.Name}
+	c.blockDecls[key] = append(c.blockDecls[key], ident)
+}
+
+// reportBlockDecls reports every block-local declaration of a name
+// that was already declared in another block of the same function.
+// These are separate variables, and none shadows another, but a reader
+// may well expect the first to persist into the later blocks.
+func (c *checker) reportBlockDecls() {
+	var keys []localName
+	for key, decls := range c.blockDecls {
+		if len(decls) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.before(c.blockDecls[keys[i]][0].Pos(), c.blockDecls[keys[j]][0].Pos())
+	})
+
+	info := c.pass.TypesInfo
+	for _, key := range keys {
+		decls := c.blockDecls[key]
+		first := info.Defs[decls[0]]
+		for _, ident := range decls[1:] {
+			c.reportIn(key.fn, kindRepeatedDecl, ident, info.Defs[ident], first,
+				"variable %q is declared again in a separate block; the declaration at %s does not persist here",
+				ident.Name, c.shortPos(first.Pos()))
+		}
+	}
+}
+
+// checkLabelCollision reports ident if it is named like a label of a
+// statement enclosing as. Labels and variables live in separate
+// namespaces, so this is purely a readability concern: "break Loop"
+// reads as if it involved the variable.
+func (c *checker) checkLabelCollision(ident *ast.Ident, as *ast.AssignStmt) {
+	for cur := c.parent.of(as); cur != nil; cur = c.parent.of(cur) {
+		switch n := cur.(type) {
+		case *ast.LabeledStmt:
+			if n.Label.Name != ident.Name {
+				continue
+			}
+			if label := c.pass.TypesInfo.Defs[n.Label]; label != nil {
+				c.report(kindLabelName, ident, c.pass.TypesInfo.Defs[ident], label,
+					"variable %q has the same name as the enclosing label declared at %s, which may confuse break and continue targets",
+					ident.Name, c.shortPos(label.Pos()))
+			}
+			return
+		case *ast.FuncDecl, *ast.FuncLit:
+			return
+		}
+	}
 }
 
-func buildParentMap(insp *inspector.Inspector) map[ast.Node]ast.Node {
-	parent := make(map[ast.Node]ast.Node)
+// processTypeSwitch handles "switch v := x.(type)". The symbolic v has
+// no object of its own; instead each case clause implicitly declares
+// one, so the first of those stands in for it. The common rebinding
+// idiom "switch x := x.(type)" is deliberate and not reported.
+func (c *checker) processTypeSwitch(ts *ast.TypeSwitchStmt, skip bool) {
+	as, ok := ts.Assign.(*ast.AssignStmt)
+	if !ok || len(as.Lhs) != 1 || len(as.Rhs) != 1 {
+		return
+	}
+	ident, ok := as.Lhs[0].(*ast.Ident)
+	if !ok || ident.Name == "_" || c.skipCgo && isCgoName(ident.Name) {
+		return
+	}
+
+	if ta, ok := ast.Unparen(as.Rhs[0]).(*ast.TypeAssertExpr); ok {
+		if x, ok := ast.Unparen(ta.X).(*ast.Ident); ok && x.Name == ident.Name {
+			return
+		}
+	}
+
+	for _, clause := range ts.Body.List {
+		if obj := c.pass.TypesInfo.Implicits[clause]; obj != nil {
+			n := len(c.findings)
+			c.checkIdent(ident, obj, as, skip)
+			if len(c.findings) > n {
+				c.findings[n].related = c.typeSwitchCases(ts)
+			}
+			return
+		}
+	}
+}
+
+// typeSwitchCases locates each case of ts that uses the variable bound
+// by its guard, along with the type the variable has there, since every
+// case clause declares a variable of its own.
+func (c *checker) typeSwitchCases(ts *ast.TypeSwitchStmt) (related []analysis.RelatedInformation) {
+	info := c.pass.TypesInfo
+	qual := types.RelativeTo(c.pass.Pkg)
+	for _, stmt := range ts.Body.List {
+		clause := stmt.(*ast.CaseClause)
+		obj := info.Implicits[clause]
+		if obj == nil || !stmtUsesOuter(clause, obj, info) {
+			continue
+		}
+		related = append(related, analysis.RelatedInformation{
+			Pos:     clause.Case,
+			End:     clause.Colon,
+			Message: fmt.Sprintf("shadow %q has type %s in this case", obj.Name(), types.TypeString(obj.Type(), qual)),
+		})
+	}
+	return
+}
+
+// checkIdent reports ident, newly declared as inner by as, if it
+// shadows an outer variable and no suppression applies.
+func (c *checker) checkIdent(ident *ast.Ident, inner types.Object, as *ast.AssignStmt, skip bool) {
+	pass := c.pass
+	outer := c.findOuter(ident, inner)
+	if outer == nil {
+		return
+	}
+	if _, ok := c.parent.of(as).(*ast.FuncLit); ok {
+		c.checkClosureParam(ident, inner, outer, as, skip)
+		return
+	}
+	if tt := testingParamType(outer, as, c.parent, pass.TypesInfo); tt != "" {
+		// Shadowing the *testing.T (or B/F) handed to a test or
+		// subtest redirects failures to the wrong test, so this
+		// is reported regardless of file filtering or allow-*.
+		c.report(kindTestingParam, ident, inner, outer,
+			"variable %q is redefined and shadows the %s parameter %q; results may be reported against the wrong test",
+			ident.Name, tt, outer.Name())
+		return
+	}
+	if skip {
+		return
+	}
+	if _, ok := inner.(*types.TypeName); ok {
+		if rule := c.skipRule(ident, inner, outer, as); rule != "" {
+			c.suppressed[rule]++
+			return
+		}
+		kind := kindTypeShadow
+		if outer.Parent() == types.Universe {
+			kind = kindPredeclared
+		}
+		c.report(kind, ident, inner, outer,
+			"type %q is redeclared and hides %s; conversions and type assertions in the rest of the block refer to the local type",
+			ident.Name, c.describe(outer))
+		return
+	}
+	if _, ok := outer.(*types.Var); !ok {
+		// An imported package, a constant, with -check-predeclared
+		// anything predeclared, with -check-func-outers a function,
+		// or with -check-nonvar-outers a function or builtin. None
+		// of the hazards below concern anything but variables.
+		if rule := c.skipRule(ident, inner, outer, as); rule != "" {
+			c.suppressed[rule]++
+			return
+		}
+		kind := kindNonVarShadow
+		if _, ok := outer.(*types.PkgName); ok {
+			kind = kindImportShadow
+		} else if outer.Parent() == types.Universe && c.checkPredeclared {
+			kind = kindPredeclared
+		} else if _, ok := outer.(*types.Func); ok {
+			c.report(kindFuncShadow, ident, inner, outer,
+				"variable %q is redefined and shadows %s, which cannot be called for the rest of the block",
+				ident.Name, c.describe(outer))
+			return
+		} else if _, ok := outer.(*types.Const); ok && outer.Parent() != types.Universe {
+			c.report(kindConstShadow, ident, inner, outer,
+				"variable %q is redefined and hides %s; the rest of the block uses the variable, not the constant",
+				ident.Name, c.describe(outer))
+			return
+		}
+		c.report(kind, ident, inner, outer,
+			"variable %q is redefined and shadows %s", ident.Name, c.describe(outer))
+		return
+	}
+	if c.checkSyncShadow {
+		if name, effect := syncPrimitive(outer.Type()); name != "" {
+			c.report(kindSync, ident, inner, outer,
+				"variable %q is redefined and shadows %q declared at %s, a %s; %s",
+				ident.Name, outer.Name(), c.shortPos(outer.Pos()), name, effect)
+			return
+		}
+	}
+	if c.checkDeferredReturnShadow {
+		if stmt := closureShadowingResult(outer, as, c.parent, pass.TypesInfo); stmt != "" {
+			c.report(kindDeferredReturn, ident, inner, outer,
+				"variable %q is redefined inside a %s closure and shadows the named result %q, so assigning to it will not affect what the function returns",
+				ident.Name, stmt, outer.Name())
+			return
+		}
+	}
+	if d := deferredNamedResultUse(outer, as, c.parent, pass.TypesInfo); d != nil {
+		c.report(kindDeferredResult, ident, inner, outer,
+			"variable %q is redefined and shadows the named result %q; the shadow causes the deferred call at %s to observe a stale named return",
+			ident.Name, outer.Name(), c.shortPos(d.Pos()))
+		return
+	}
+	if c.checkNamedReturns {
+		if _, result := declaringFunc(outer, as, c.parent, pass.TypesInfo); result {
+			c.report(kindNamedResult, ident, inner, outer,
+				"variable %q is redefined and shadows the named return value %q, so assignments to it do not change what the function returns",
+				ident.Name, outer.Name())
+			return
+		}
+	}
+	if c.warnFuncVarShadow && isFuncVarShadow(outer, as, c.parent, pass.TypesInfo) {
+		c.report(kindFuncVar, ident, inner, outer,
+			"variable %q is redefined and shadows the function value %q, which is still called with its old value afterwards",
+			ident.Name, outer.Name())
+		return
+	}
+	if c.warnGoroutineShadow && isGoroutineShadow(outer, as, c.parent, pass.TypesInfo) {
+		c.report(kindGoroutine, ident, inner, outer,
+			"variable %q is redefined inside a goroutine and shadows %q, which both the goroutine and the function starting it use; the shadow may hide a data race",
+			ident.Name, outer.Name())
+		return
+	}
+	if c.checkErrorChain && c.isErrPair(inner, outer) {
+		if fn := errorChainCheck(inner, as, c.parent, pass.TypesInfo); fn != "" {
+			c.report(kindErrorChain, ident, inner, outer,
+				"variable %q is redefined and shadows %q declared at %s, then inspected with %s; the outer error and whatever it wraps are out of reach here",
+				ident.Name, outer.Name(), c.shortPos(outer.Pos()), fn)
+			return
+		}
+	}
+	if c.warnDroppedError && c.isErrPair(inner, outer) {
+		if ret := c.droppedError(ident, inner, outer, as); ret != nil {
+			c.report(kindDroppedError, ident, inner, outer,
+				"variable %q is redefined and shadows an outer %q declared at %s, but is never checked or returned, while the outer %q is returned at %s; the error is likely lost",
+				ident.Name, outer.Name(), c.shortPos(outer.Pos()), outer.Name(), c.shortPos(ret.Pos()))
+			return
+		}
+	}
+	if c.warnErrTreadmill && c.isErrPair(inner, outer) {
+		if n := c.countTreadmill(outer, as); n > treadmillLimit {
+			c.report(kindErrTreadmill, ident, inner, outer,
+				"variable %q is redeclared in the init of %d sibling if statements, each shadowing the outer %q declared at %s; consider assigning to the outer with '=' instead",
+				ident.Name, n, outer.Name(), c.shortPos(outer.Pos()))
+			return
+		}
+	}
+	if c.checkBranchConsistency {
+		if sib := siblingAssign(outer, as, c.parent, pass.TypesInfo); sib != nil {
+			c.report(kindBranchMismatch, ident, inner, outer,
+				"variable %q is redefined with ':=' in this branch, while a sibling branch assigns to the outer %q with '=' at %s; the value set here does not reach the outer variable",
+				ident.Name, outer.Name(), c.shortPos(sib.Pos()))
+			return
+		}
+	}
+	if c.warnContextShadow {
+		if fn := contextShadow(inner, ident, as, c.parent, pass.TypesInfo); fn != "" {
+			c.report(kindContext, ident, inner, outer,
+				"variable %q is redefined by %s and shadows %q declared at %s; neither the derived context nor its cancel func leaves this block, so code after it still uses the outer context",
+				ident.Name, fn, outer.Name(), c.shortPos(outer.Pos()))
+			return
+		}
+	}
+	if c.warnPoolShadow && isPoolShadow(outer, ident, as, c.fileOf(outer.Pos()), pass.TypesInfo) {
+		c.report(kindPool, ident, inner, outer,
+			"variable %q is redefined with a fresh allocation and shadows %q obtained from a sync.Pool, which defeats the pool",
+			ident.Name, outer.Name())
+		return
+	}
+	if isAssertedValueShadow(outer, ident, as, c.parent, pass.TypesInfo) {
+		// The asserted value is discarded before it is ever
+		// read; this is almost never intended, so no allow-*
+		// rule applies.
+		c.report(kindTypeAssert, ident, inner, outer,
+			"variable %q is redefined and shadows %q bound by the enclosing type assertion before the asserted value is used",
+			ident.Name, outer.Name())
+		return
+	}
+	if c.warnTypeChange && !types.Identical(inner.Type(), outer.Type()) {
+		// The inner is a different kind of thing altogether, which
+		// is more than the allow-* rules were meant to excuse.
+		qual := types.RelativeTo(pass.Pkg)
+		c.report(kindTypeChange, ident, inner, outer,
+			"variable %q is redefined with a different type (%s vs %s) and shadows an outer %q declared at %s",
+			ident.Name, types.TypeString(inner.Type(), qual), types.TypeString(outer.Type(), qual),
+			outer.Name(), c.shortPos(outer.Pos()))
+		return
+	}
+	if prev := elseIfLink(ident.Name, outer, as, c.parent, pass.TypesInfo); prev != nil {
+		// Every clause after the first shadowing link of the chain
+		// is folded into the diagnostic of that link.
+		if pp, ok := c.parent.of(prev).(*ast.IfStmt); ok && pp.Else == prev && initDecl(pp, ident.Name, pass.TypesInfo) != nil {
+			return
+		}
+		if rule := c.skipRule(ident, inner, outer, as); rule != "" {
+			c.suppressed[rule]++
+			return
+		}
+		links := elseIfChain(ident.Name, prev, pass.TypesInfo)
+		where := make([]string, len(links))
+		for i, id := range links {
+			where[i] = c.shortPos(id.Pos())
+		}
+		c.report(kindElseIfChain, ident, inner, outer,
+			"variable %q is redeclared in the init of %d clauses of an else-if chain, each shadowing the one before, at %s",
+			ident.Name, len(links)+1, strings.Join(where, ", "))
+		f := &c.findings[len(c.findings)-1]
+		for _, id := range links[1:] {
+			f.related = append(f.related, analysis.RelatedInformation{
+				Pos:     id.Pos(),
+				End:     id.End(),
+				Message: fmt.Sprintf("%q redeclared again here", id.Name),
+			})
+		}
+		return
+	}
+	if c.ssaSites != nil {
+		switch c.lostWrite(ident, outer, ownerFuncBody(outer, as, c.parent)) {
+		case ssaLost:
+			c.report(kindLostWrite, ident, inner, outer,
+				"variable %q is redefined and shadows %q declared at %s, which is read afterwards on a path where only the shadow was assigned; the write is lost",
+				ident.Name, outer.Name(), c.shortPos(outer.Pos()))
+			return
+		case ssaHarmless:
+			c.suppressed["ssa"]++
+			return
+		}
+	}
+	kind := c.shadowKind(ident, outer, as)
+	if kind != kindParamShadow && kind != kindRecvShadow || !c.paramsStrict {
+		if c.checkRedundantLoopCopy && isLoopVarCopy(as, c.parent, pass.TypesInfo) && c.perIterationLoopVars(as) {
+			// This includes table-test copies, so allow-table-tests
+			// does not apply either.
+			c.report(kindLoopCopy, ident, inner, outer,
+				"variable %q is redefined as a copy of the loop variable, which is unnecessary as of Go 1.22, since each iteration has a variable of its own",
+				ident.Name)
+			c.findings[len(c.findings)-1].copy = as
+			return
+		}
+		if rule := c.skipRule(ident, inner, outer, as); rule != "" {
+			c.suppressed[rule]++
+			return
+		}
+	}
+	format := "variable %q is redefined and shadows an outer %q declared at %s"
+	switch kind {
+	case kindTypeChange:
+		qual := types.RelativeTo(pass.Pkg)
+		format = fmt.Sprintf("variable %%q is redefined with a different type (%s vs %s) and shadows an outer %%q declared at %%s",
+			types.TypeString(inner.Type(), qual), types.TypeString(outer.Type(), qual))
+	case kindParamShadow:
+		format = "variable %q is redefined and shadows the parameter %q declared at %s"
+	case kindRecvShadow:
+		format = "variable %q is redefined and shadows the receiver %q declared at %s, which is unreachable for the rest of the block"
+	case kindRebind:
+		format = "variable %q is redefined from the outer %q declared at %s, rebinding it for the rest of the block"
+	case kindChanShadow:
+		format = "variable %q is redefined and shadows the channel %q declared at %s; sends, receives and closes in the rest of the block use a different channel, which may deadlock"
+	case kindDeferShadow:
+		format = "variable %q is redefined inside a deferred closure and hides the outer %q declared at %s; the outer value will not be updated"
+	case kindGoShadow:
+		format = "variable %q is redefined inside a goroutine and hides the outer %q declared at %s; values assigned to it never reach the outer variable"
+	case kindClosureShadow:
+		format = "variable %q is redefined inside a closure and shadows %q declared at %s in the enclosing function"
+		if _, captured := capturedOuter(outer, as, c.parent, pass.TypesInfo); captured {
+			format += ", which the closure also captures"
+		}
+	}
+	used, written := true, false
+	if c.warnUnusedInner {
+		used, written = innerUsed(inner, findEnclosingBlock(as, c.parent), pass.TypesInfo)
+	}
+	if !used && written {
+		format += "; the inner variable is only ever assigned to, never read"
+	} else if !used {
+		format += "; the inner variable is never used, so the declaration may be dropped"
+	} else if c.suggestReuse && c.reusable(as) {
+		format += "; consider assigning to the outer variable with '=' instead of ':='"
+		if c.reuse == nil {
+			c.reuse = make(map[*ast.Ident]*ast.AssignStmt)
+		}
+		for _, lhs := range as.Lhs {
+			c.reuse[lhs.(*ast.Ident)] = as
+		}
+	} else if c.suggestReuse {
+		if fresh := c.freshNames(as); len(fresh) > 0 {
+			verb, pron := "is", "it"
+			if len(fresh) > 1 {
+				verb, pron = "are", "them"
+			}
+			format += fmt.Sprintf("; %s %s new, so assigning to the outer variable with '=' requires declaring %s separately",
+				quoteList(fresh), verb, pron)
+		}
+	}
+	if c.warnReadAfterShadow {
+		if next := readAfterShadow(outer, as, c.parent, pass.TypesInfo); next != nil {
+			format += fmt.Sprintf("; the outer %q is read right after the block, at line %d, but never assigned within it, so the value given to the shadow is lost",
+				outer.Name(), c.pass.Fset.Position(next.Pos()).Line)
+		}
+	}
+	chain := c.hiddenChain(outer)
+	if len(chain) > 0 {
+		where := make([]string, len(chain))
+		for i, obj := range chain {
+			where[i] = strings.ReplaceAll(c.shortPos(obj.Pos()), "%", "%%")
+		}
+		format += fmt.Sprintf("; %q is declared %d times in nested scopes here, also at %s",
+			ident.Name, len(chain)+2, strings.Join(where, ", "))
+	}
+	c.report(kind, ident, inner, outer,
+		format, ident.Name, ident.Name, c.shortPos(outer.Pos()))
+	for _, obj := range chain {
+		f := &c.findings[len(c.findings)-1]
+		f.related = append(f.related, analysis.RelatedInformation{
+			Pos:     obj.Pos(),
+			End:     obj.Pos() + token.Pos(len(obj.Name())),
+			Message: fmt.Sprintf("%q also hidden here", obj.Name()),
+		})
+	}
+}
+
+// hiddenChain returns the local variables that outer itself shadows,
+// innermost first, as in the first two declarations of x for a third
+// one nested within them.
+func (c *checker) hiddenChain(outer types.Object) (chain []types.Object) {
+	pkgScope := c.pass.Pkg.Scope()
+	for obj := outer; ; {
+		if _, ok := obj.(*types.Var); !ok || obj.Parent() == nil || obj.Parent() == pkgScope {
+			return
+		}
+		ident := &ast.Ident{NamePos: obj.Pos(), Name: obj.Name()}
+		next := c.findOuter(ident, obj)
+		if v, ok := next.(*types.Var); !ok || v.Parent() == pkgScope {
+			return
+		}
+		chain = append(chain, next)
+		obj = next
+	}
+}
+
+// checkClosureParam reports the parameter ident of a function literal
+// shadowing outer, with -check-closure-params. A subtest's
+// "func(t *testing.T)" hiding the parent test's t is the idiom, and is
+// left alone.
+func (c *checker) checkClosureParam(ident *ast.Ident, inner, outer types.Object, as *ast.AssignStmt, skip bool) {
+	if skip {
+		return
+	}
+	if tt := testingParamType(outer, as, c.parent, c.pass.TypesInfo); tt != "" && types.Identical(inner.Type(), outer.Type()) {
+		return
+	}
+	if rule := c.skipRule(ident, inner, outer, as); rule != "" {
+		c.suppressed[rule]++
+		return
+	}
+	c.report(kindClosureParam, ident, inner, outer,
+		"parameter %q of the function literal shadows %s, which is out of reach inside the literal",
+		ident.Name, c.describe(outer))
+}
+
+// treadmillLimit is the number of sibling if statements whose init may
+// shadow the same error variable before -warn-err-treadmill complains.
+const treadmillLimit = 2
+
+// countTreadmill counts as if it is the init statement of an if
+// statement shadowing outer, and returns the number of such statements
+// in the enclosing block so far, or 0 if as is no such init.
+func (c *checker) countTreadmill(outer types.Object, as *ast.AssignStmt) int {
+	ifStmt, ok := c.parent.of(as).(*ast.IfStmt)
+	if !ok || ifStmt.Init != as {
+		return 0
+	}
+	block, ok := c.parent.of(ifStmt).(*ast.BlockStmt)
+	if !ok {
+		// e.g. the if of an else if, which has no siblings
+		return 0
+	}
+
+	key := treadmill{block, outer}
+	if c.treadmills == nil {
+		c.treadmills = make(map[treadmill]map[*ast.IfStmt]bool)
+	}
+	if c.treadmills[key] == nil {
+		c.treadmills[key] = make(map[*ast.IfStmt]bool)
+	}
+	c.treadmills[key][ifStmt] = true
+	return len(c.treadmills[key])
+}
+
+// reusable reports whether as could assign with "=" instead of
+// declaring anything with ":=", which holds when each variable it
+// declares shadows a variable of identical type, to which the name
+// would then resolve.
+func (c *checker) reusable(as *ast.AssignStmt) bool {
+	switch p := c.parent.of(as).(type) {
+	case *ast.TypeSwitchStmt:
+		if p.Assign == as {
+			return false
+		}
+	case *ast.ValueSpec:
+		// a var declaration, which has no ":=" to replace
+		return false
+	case *ast.RangeStmt:
+		// "for k, v = range" would also give up the variables of
+		// each iteration, which closures in the body may rely on.
+		return false
+	}
+
+	for _, lhs := range as.Lhs {
+		id, ok := lhs.(*ast.Ident)
+		if !ok {
+			return false
+		}
+		inner := c.pass.TypesInfo.Defs[id]
+		if inner == nil || id.Name == "_" {
+			// already declared in this scope, or blank
+			continue
+		}
+		scope := inner.Parent()
+		if scope == nil || scope.Parent() == nil {
+			return false
+		}
+		_, outer := scope.Parent().LookupParent(id.Name, id.Pos())
+		if v, ok := outer.(*types.Var); !ok || !types.Identical(inner.Type(), v.Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+// freshNames returns the names declared by a partial redefinition as,
+// i.e. a := declaring both variables shadowing outer ones and variables
+// shadowing nothing, as in "a, err := f()" with an outer err. These are
+// the names that keep the statement from being turned into "=" as it
+// stands. Nil is returned if as is no such statement.
+func (c *checker) freshNames(as *ast.AssignStmt) []string {
+	var fresh []string
+	shadows := false
+	for _, lhs := range as.Lhs {
+		id, ok := lhs.(*ast.Ident)
+		if !ok || id.Name == "_" {
+			continue
+		}
+		inner := c.pass.TypesInfo.Defs[id]
+		if inner == nil {
+			// already declared in this scope
+			continue
+		}
+		if c.findOuter(id, inner) != nil {
+			shadows = true
+		} else {
+			fresh = append(fresh, id.Name)
+		}
+	}
+	if !shadows {
+		return nil
+	}
+	return fresh
+}
+
+// quoteList joins the quoted names, as in `"a", "b" and "c"`.
+func quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = strconv.Quote(name)
+	}
+	if len(quoted) == 1 {
+		return quoted[0]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " and " + quoted[len(quoted)-1]
+}
+
+// isLoopVarCopy reports whether as is "v := v" directly within the body
+// of a for or range statement declaring v.
+func isLoopVarCopy(as *ast.AssignStmt, parent ancestors, info *types.Info) bool {
+	if len(as.Lhs) != 1 || len(as.Rhs) != 1 {
+		return false
+	}
+	lhs, ok := as.Lhs[0].(*ast.Ident)
+	if !ok {
+		return false
+	}
+	rhs, ok := as.Rhs[0].(*ast.Ident)
+	if !ok || rhs.Name != lhs.Name {
+		return false
+	}
+
+	var vars []ast.Expr
+	body, ok := parent.of(as).(*ast.BlockStmt)
+	if !ok {
+		return false
+	}
+	switch loop := parent.of(body).(type) {
+	case *ast.RangeStmt:
+		if loop.Body == body && loop.Tok == token.DEFINE {
+			vars = []ast.Expr{loop.Key, loop.Value}
+		}
+	case *ast.ForStmt:
+		if init, ok := loop.Init.(*ast.AssignStmt); ok && loop.Body == body && init.Tok == token.DEFINE {
+			vars = init.Lhs
+		}
+	}
+	for _, v := range vars {
+		if id, ok := v.(*ast.Ident); ok && info.Defs[id] != nil && info.Defs[id] == info.Uses[rhs] {
+			return true
+		}
+	}
+	return false
+}
+
+// perIterationLoopVars reports whether the file containing n is known
+// to be compiled as Go 1.22 or later, where each iteration of a loop
+// declares its variables anew. The file's own version, which a
+// //go:build line may set, takes precedence over the package's.
+func (c *checker) perIterationLoopVars(n ast.Node) bool {
+	v := c.pass.Pkg.GoVersion()
+	if f := c.fileOf(n.Pos()); f != nil && c.pass.TypesInfo.FileVersions[f] != "" {
+		v = c.pass.TypesInfo.FileVersions[f]
+	}
+	return version.Compare(v, "go1.22") >= 0
+}
+
+// innerUsed reports whether inner is read within block, not counting
+// blank assignments such as "_ = x", which only serve to placate the
+// compiler, nor plain assignments to it, as in "x = g()", which written
+// reports.
+func innerUsed(inner types.Object, block *ast.BlockStmt, info *types.Info) (used, written bool) {
+	if block == nil {
+		return true, false
+	}
+
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		if as, ok := n.(*ast.AssignStmt); ok && as.Tok == token.ASSIGN {
+			for _, lhs := range as.Lhs {
+				if id, ok := ast.Unparen(lhs).(*ast.Ident); ok {
+					written = written || info.Uses[id] == inner
+				} else {
+					ast.Inspect(lhs, visit)
+				}
+			}
+			blank := allBlank(as.Lhs)
+			for _, rhs := range as.Rhs {
+				// skip the right-hand side of "_ = x", but not
+				// any closures in it
+				if _, ok := ast.Unparen(rhs).(*ast.Ident); !ok || !blank {
+					ast.Inspect(rhs, visit)
+				}
+			}
+			return false
+		}
+		if id, ok := n.(*ast.Ident); ok && info.Uses[id] == inner {
+			used = true
+		}
+		return !used
+	}
+	ast.Inspect(block, visit)
+
+	return used, written
+}
+
+// allBlank reports whether every expression of exprs is the blank
+// identifier.
+func allBlank(exprs []ast.Expr) bool {
+	for _, e := range exprs {
+		if id, ok := e.(*ast.Ident); !ok || id.Name != "_" {
+			return false
+		}
+	}
+	return true
+}
+
+// skipRule returns the name of the first allow-* rule that suppresses
+// the shadowing of outer by ident, or an empty string if none does.
+func (c *checker) skipRule(
+	ident *ast.Ident,
+	inner, outer types.Object,
+	as *ast.AssignStmt,
+) (rule string) {
+	parent := c.parent
+
+	// nearest block (may be inner block, e.g., if body)
+	block := findEnclosingBlock(as, parent)
+	if block == nil {
+		return
+	}
+
+	// immediate owning statement (may be the AssignStmt itself, or an ExprStmt, etc.)
+	stmt := findOwningStmt(as, parent)
+	if stmt == nil {
+		return
+	}
+
+	// body of the function declaring outer, which may enclose the
+	// closure holding as, and the statement of that body containing as
+	funcBody := ownerFuncBody(outer, as, parent)
+	topStmt := stmt
+	if funcBody != nil {
+		topStmt = findTopLevelStmt(stmt, parent, funcBody)
+	}
+
+	// Evaluate skip checks. Guard-only detection works on the
+	// top-level statement of the function body, while dead-outer
+	// detection scans everything after stmt in the outermost function.
+	var fired []string
+	for _, check := range []struct {
+		rule string
+		skip bool
+	}{
+		{"allow-short-init", c.skipForShortInit(as)},
+		{"allow-same-line", c.skipForSameLine(ident, outer)},
+		{"allow-loop-shadow", c.skipForLoopShadow(as)},
+		{"allow-for-init", c.skipForForInit(as)},
+		{"allow-ok-shadow", c.skipForOkShadow(as, ident)},
+		{"allow-dead-outer", c.skipForDeadOuter(outer, stmt, outermostFuncBody(parent))},
+		{"allow-err-shadow", c.skipForErrShadow(inner, outer)},
+		// use topStmt and funcBody for guard-only detection
+		{"allow-guard-shadow", c.skipForGuardShadow(outer, topStmt, funcBody)},
+		{"allow-table-tests", c.skipForTableTests(as)},
+		{"allow-names", c.skipForAllowedName(ident, outer)},
+		{"allow-name-prefix", c.allowNamePrefix != "" && strings.HasPrefix(ident.Name, c.allowNamePrefix)},
+		{"allow-name-suffix", c.allowNameSuffix != "" && strings.HasSuffix(ident.Name, c.allowNameSuffix)},
+		{"allow-capture-shadow", c.skipForCaptureShadow(inner, block)},
+		{"allow-import-shadow", c.skipForImportShadow(outer)},
+		{"allow-rebind", c.allowRebind && isRebind(outer, as, c.pass.TypesInfo)},
+		{"allow-else-if-chain", c.allowElseIfChain && elseIfLink(ident.Name, outer, as, c.parent, c.pass.TypesInfo) != nil},
+		{"allow-const-shadow", c.skipForConstShadow(outer)},
+		{"allow-goroutine-shadow", c.skipForGoroutineShadow(outer, as)},
+		{"min-scope-depth", c.skipForScopeDepth(inner, outer)},
+	} {
+		if check.skip {
+			fired = append(fired, check.rule)
+		}
+	}
+	if len(fired) > 0 {
+		rule = fired[0]
+	}
+
+	if c.explain {
+		verdict := "reported"
+		if len(fired) > 0 {
+			verdict = "suppressed by " + strings.Join(fired, ", ")
+		}
+		enabled := strings.Join(c.enabledRules(), ", ")
+		if enabled == "" {
+			enabled = "none"
+		}
+		c.explanations = append(c.explanations, explanation{
+			pos: ident.Pos(),
+			text: fmt.Sprintf("%q shadowing %s: %s; rules enabled: %s",
+				ident.Name, c.shortPos(outer.Pos()), verdict, enabled),
+		})
+	}
+
+	return
+}
+
+// explanation records, for -explain, which allow-* rules fired for a
+// shadow.
+type explanation struct {
+	pos  token.Pos
+	text string
+}
+
+// explainOutput receives the -explain output, a line per shadow.
+var (
+	explainOutput io.Writer = os.Stderr
+	explainMu     sync.Mutex
+)
+
+// writeExplanations writes the explanations collected for -explain in
+// order of position, keeping those of a package together.
+func (c *checker) writeExplanations() {
+	sort.SliceStable(c.explanations, func(i, j int) bool {
+		return c.before(c.explanations[i].pos, c.explanations[j].pos)
+	})
+
+	explainMu.Lock()
+	defer explainMu.Unlock()
+	for _, e := range c.explanations {
+		fmt.Fprintf(explainOutput, "%s: redef: %s\n", c.pass.Fset.Position(e.pos), e.text)
+	}
+}
+
+// shadowKind classifies a plain shadow of outer by ident, checking in
+// turn for a change of type, a receiver or parameter shadow, a shadow
+// inside a deferred, go or other closure of a variable of the enclosing
+// function, an err shadow, the ok of a comma-ok form, a channel, the
+// init statement of a for, a shadow inside a loop, a shadow following
+// guard-only uses of outer, a table-test copy and a rebind. Unlike the
+// allow-guard-shadow rule, a guard shadow needs at least one such use.
+func (c *checker) shadowKind(ident *ast.Ident, outer types.Object, as *ast.AssignStmt) string {
+	parent := c.parent
+	if inner := c.pass.TypesInfo.Defs[ident]; inner != nil && !types.Identical(inner.Type(), outer.Type()) {
+		return kindTypeChange
+	}
+	if isParam(outer, as, parent, c.pass.TypesInfo) {
+		if v, ok := outer.(*types.Var); ok && v.Kind() == types.RecvVar {
+			return kindRecvShadow
+		}
+		return kindParamShadow
+	}
+	if lit, _ := capturedOuter(outer, as, parent, c.pass.TypesInfo); lit != nil {
+		if _, stmt := launchedClosure(as, parent); stmt != nil {
+			switch stmt.(type) {
+			case *ast.DeferStmt:
+				return kindDeferShadow
+			case *ast.GoStmt:
+				return kindGoShadow
+			}
+		}
+		return kindClosureShadow
+	}
+	if c.isErrPair(c.pass.TypesInfo.Defs[ident], outer) {
+		return kindErrShadow
+	}
+	if isCommaOk(as, ident) {
+		return kindOkShadow
+	}
+	if _, ok := outer.Type().Underlying().(*types.Chan); ok {
+		return kindChanShadow
+	}
+	if c.isForInit(as) {
+		return kindForInit
+	}
+	if inLoop(as, parent) {
+		return kindLoopShadow
+	}
+	if stmt, body := findOwningStmt(as, parent), ownerFuncBody(outer, as, parent); body != nil {
+		top := findTopLevelStmt(stmt, parent, body)
+		if usedBefore(outer, top, body, c.pass.TypesInfo) &&
+			isGuardClauseOnly(outer, top, body, c.pass.TypesInfo) {
+			return kindGuardShadow
+		}
+	}
+	if c.isTableTest(as) {
+		return kindTableShadow
+	}
+	if isRebind(outer, as, c.pass.TypesInfo) {
+		return kindRebind
+	}
+	return kindShadow
+}
+
+// isRebind reports whether the right-hand side of as reads outer, as
+// in "x := x + 1" or "s := strings.TrimSpace(s)", which derives the
+// inner variable from the outer on purpose.
+func isRebind(outer types.Object, as *ast.AssignStmt, info *types.Info) bool {
+	if len(as.Lhs) == 0 {
+		return false
+	}
+	if _, ok := as.Lhs[0].(*ast.Ident); !ok {
+		return false
+	}
+	for _, rhs := range as.Rhs {
+		used := false
+		ast.Inspect(rhs, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			used = used || ok && info.Uses[id] == outer
+			return !used
+		})
+		if used {
+			return true
+		}
+	}
+	return false
+}
+
+// skipForShortInit reports whether as is the init statement of an if,
+// for, switch or type switch statement, whose variables are confined
+// to that statement.
+func (c *checker) skipForShortInit(as *ast.AssignStmt) bool {
+	if !c.allowShortInit {
+		return false
+	}
+
+	switch s := c.parent.of(as).(type) {
+	case *ast.IfStmt:
+		return s.Init == as
+	case *ast.ForStmt:
+		return s.Init == as
+	case *ast.SwitchStmt:
+		return s.Init == as
+	case *ast.TypeSwitchStmt:
+		return s.Init == as
+	}
+	return false
+}
+
+// skipForSameLine reports whether ident and outer are declared on the
+// same line by one construct, as in "if x := f(); x > 0 { x := g() }".
+// Separate statements that merely share a line, as in "x := 1; { x :=
+// 2 }", do not count: the innermost node around ident that also holds
+// the declaration of outer must not be a block.
+func (c *checker) skipForSameLine(ident *ast.Ident, outer types.Object) bool {
+	if !c.allowSameLine ||
+		c.pass.Fset.Position(ident.Pos()).Line != c.pass.Fset.Position(outer.Pos()).Line {
+		return false
+	}
+
+	for i := len(c.parent) - 1; i >= 0; i-- {
+		if n := c.parent[i]; n.Pos() <= outer.Pos() && outer.Pos() < n.End() {
+			_, block := n.(*ast.BlockStmt)
+			return !block
+		}
+	}
+	return false
+}
+
+// skipForLoopShadow reports whether as is within the body of a for or
+// range statement of the current function, or declares the key or
+// value of a range. The init statement of a for is left to
+// allow-for-init.
+func (c *checker) skipForLoopShadow(as *ast.AssignStmt) bool {
+	return c.allowLoopShadow && !c.isForInit(as) && inLoop(as, c.parent)
+}
+
+func (c *checker) skipForOkShadow(as *ast.AssignStmt, ident *ast.Ident) bool {
+	return c.allowOkShadow && isCommaOk(as, ident)
+}
+
+// isCommaOk reports whether ident is the second variable of a comma-ok
+// form declared by as: a map index, type assertion or channel receive,
+// as in "v, ok := m[k]".
+func isCommaOk(as *ast.AssignStmt, ident *ast.Ident) bool {
+	if len(as.Lhs) != 2 || len(as.Rhs) != 1 || as.Lhs[1] != ident {
+		return false
+	}
+	// A slice, array or string index has no comma-ok form, so two
+	// variables mean a map.
+	switch rhs := ast.Unparen(as.Rhs[0]).(type) {
+	case *ast.IndexExpr, *ast.TypeAssertExpr:
+		return true
+	case *ast.UnaryExpr:
+		return rhs.Op == token.ARROW
+	}
+	return false
+}
+
+func (c *checker) skipForForInit(as *ast.AssignStmt) bool {
+	return c.allowForInit && c.isForInit(as)
+}
+
+// isForInit reports whether as is the init statement of a for
+// statement, as in "for i := 0; i < n; i++".
+func (c *checker) isForInit(as *ast.AssignStmt) bool {
+	loop, ok := c.parent.of(as).(*ast.ForStmt)
+	return ok && loop.Init == as
+}
+
+func (c *checker) skipForDeadOuter(
+	outer types.Object,
+	stmt ast.Stmt,
+	block *ast.BlockStmt,
+) (allow bool) {
+	if c.allowDeadOuter {
+		allow = !outerUsedLater(outer, stmt, block, c.pass.TypesInfo) && c.allowDeadOuter
+	}
+
+	return
+}
+
+func (c *checker) skipForErrShadow(inner, outer types.Object) (allow bool) {
+	if c.allowErrShadow {
+		allow = c.isErrPair(inner, outer)
+	}
+	return
+}
+
+// isErrPair reports whether inner and outer are both error variables:
+// either both names match -err-name-pattern, or both types implement
+// error.
+func (c *checker) isErrPair(inner, outer types.Object) bool {
+	if inner == nil {
+		return false
+	}
+	if c.errNamePattern.MatchString(inner.Name()) && c.errNamePattern.MatchString(outer.Name()) {
+		return true
+	}
+	return isError(inner.Type()) && isError(outer.Type())
+}
+
+// defaultErrNamePattern matches err and names such as rerr or closeErr,
+// but not errCount or stderr.
+const defaultErrNamePattern = `^[a-z]?err$|Err$`
+
+// errorType is the type of the predeclared error interface.
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+func isError(t types.Type) bool {
+	return types.Implements(t, errorType)
+}
+
+func (c *checker) skipForGuardShadow(outer types.Object, stmt ast.Stmt, block *ast.BlockStmt) bool {
+	return isGuardClauseOnly(outer, stmt, block, c.pass.TypesInfo) && c.allowGuardShadow
+}
+
+func (c *checker) skipForTableTests(as *ast.AssignStmt) bool {
+	return c.allowTableTests && c.isTableTest(as)
+}
+
+// isTableTest reports whether as is the per-iteration copy of a table
+// test's range variable, made in test code: a _test.go file, or a
+// function whose name matches one of -table-test-funcs.
+func (c *checker) isTableTest(as *ast.AssignStmt) bool {
+	if !isTableTestPattern(as, c.parent, c.pass.TypesInfo, c.tableTestRenames) {
+		return false
+	}
+	if strings.HasSuffix(c.pass.Fset.Position(as.Pos()).Filename, "_test.go") {
+		return true
+	}
+	for _, n := range c.parent {
+		if fd, ok := n.(*ast.FuncDecl); ok {
+			return c.tableTestFuncs.match(fd.Name.Name)
+		}
+	}
+	return false
+}
+
+// excluded reports whether the function declaration enclosing the
+// current statement matches one of -exclude-funcs, either as is, e.g.
+// "(*Server).Handle", or qualified by the package name, e.g.
+// "pkg.Init", or is a package init function with -skip-init-funcs.
+// Names match exactly, unless given as patterns, e.g. "glob:pkg.Test*".
+func (c *checker) excluded() bool {
+	if len(c.excludeFuncs) == 0 && !c.skipInitFuncs {
+		return false
+	}
+	for _, n := range c.parent {
+		if fd, ok := n.(*ast.FuncDecl); ok {
+			if c.skipInitFuncs && isInitFunc(fd) {
+				return true
+			}
+			name := qualifiedName(fd)
+			return c.excludeFuncs.matchFunc(name) || c.excludeFuncs.matchFunc(c.pass.Pkg.Name()+"."+name)
+		}
+	}
+	return false
+}
+
+// isInitFunc reports whether fd declares a package initialization
+// function: func init() without receiver, type parameters, parameters
+// or results. Anything else named init is an ordinary function, or
+// a compile error.
+func isInitFunc(fd *ast.FuncDecl) bool {
+	return fd.Name.Name == "init" && fd.Recv == nil && fd.Type.TypeParams == nil &&
+		fd.Type.Params.NumFields() == 0 && fd.Type.Results.NumFields() == 0
+}
+
+// qualifiedName names fd as "Foo", or as "(T).Foo" or "(*T).Foo" if it
+// is a method, leaving out any type parameters of the receiver.
+func qualifiedName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+
+	typ, star := fd.Recv.List[0].Type, ""
+	if ptr, ok := typ.(*ast.StarExpr); ok {
+		typ, star = ptr.X, "*"
+	}
+	switch t := typ.(type) {
+	case *ast.IndexExpr:
+		typ = t.X
+	case *ast.IndexListExpr:
+		typ = t.X
+	}
+	return fmt.Sprintf("(%s%s).%s", star, types.ExprString(typ), fd.Name.Name)
+}
+
+func (c *checker) skipForImportShadow(outer types.Object) bool {
+	_, ok := outer.(*types.PkgName)
+	return ok && c.allowImportShadow
+}
+
+func (c *checker) skipForConstShadow(outer types.Object) bool {
+	_, ok := outer.(*types.Const)
+	return ok && outer.Parent() != types.Universe && c.allowConstShadow
+}
+
+func (c *checker) skipForCaptureShadow(inner types.Object, block *ast.BlockStmt) bool {
+	return c.allowCaptureShadow && capturedByGoOrDefer(inner, block, c.pass.TypesInfo)
+}
+
+// skipForGoroutineShadow reports whether as lies directly within a
+// function literal started by a go statement, and outer is a local
+// variable of an enclosing function.
+func (c *checker) skipForGoroutineShadow(outer types.Object, as *ast.AssignStmt) bool {
+	if !c.allowGoroutineShadow {
+		return false
+	}
+	if lit, _ := capturedOuter(outer, as, c.parent, c.pass.TypesInfo); lit == nil {
+		return false
+	}
+	_, stmt := launchedClosure(as, c.parent)
+	_, ok := stmt.(*ast.GoStmt)
+	return ok
+}
+
+func (c *checker) skipForScopeDepth(inner, outer types.Object) bool {
+	return c.minScopeDepth > 0 && c.scopeDepth(inner, outer) < c.minScopeDepth
+}
+
+// scopeNodes maps each scope recorded in info back to its node.
+func scopeNodes(info *types.Info) map[*types.Scope]ast.Node {
+	nodes := make(map[*types.Scope]ast.Node, len(info.Scopes))
+	for n, scope := range info.Scopes {
+		nodes[scope] = n
+	}
+	return nodes
+}
+
+// scopeDepth returns how many blocks deeper than outer the variable
+// inner is declared. The implicit scope that go/types opens around an
+// if, for, switch or select statement is not counted on its own, so
+// that the body of an if statement is one level deep, whether or not
+// the shadow is declared in the if's init statement or its body.
+func (c *checker) scopeDepth(inner, outer types.Object) (depth int) {
+	if c.scopeNodes == nil {
+		c.scopeNodes = scopeNodes(c.pass.TypesInfo)
+	}
+
+	for scope := inner.Parent(); scope != nil && scope != outer.Parent(); scope = scope.Parent() {
+		switch c.scopeNodes[scope].(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt,
+			*ast.SwitchStmt, *ast.TypeSwitchStmt:
+			if scope != inner.Parent() {
+				continue
+			}
+		}
+		depth++
+	}
+
+	return
+}
+
+func (c *checker) skipForAllowedName(ident *ast.Ident, outer types.Object) bool {
+	return c.allowNames.has(ident.Name) || c.allowNames.has(outer.Name())
+}
+
+func (c *checker) findOuter(ident *ast.Ident, inner types.Object) types.Object {
+	name := ident.Name
+	scope := inner.Parent()
+	if scope == nil {
+		return nil
+	}
+
+	pkgScope := c.pass.Pkg.Scope()
+	for s := scope.Parent(); s != nil; s = s.Parent() {
+		obj := s.Lookup(name)
+		if obj == nil {
+			continue
+		}
+		switch {
+		case s == types.Universe || s.Parent() == pkgScope:
+			// predeclared, or imported into the file
+		case s == pkgScope:
+			// Package-level objects are visible throughout
+			// the package, wherever they are declared, and
+			// positions are not comparable across files, so
+			// by default only those declared earlier in the
+			// same file count. Types and functions are
+			// commonly declared after their uses, or in
+			// another file, so those count wherever they are.
+			_, typ := inner.(*types.TypeName)
+			_, fn := obj.(*types.Func)
+			if !typ && !fn && !c.includePackageScope &&
+				(c.pass.Fset.File(obj.Pos()) != c.pass.Fset.File(ident.Pos()) || obj.Pos() >= ident.Pos()) {
+				continue
+			}
+		default:
+			// Only treat it as an outer object if it is in
+			// scope at ident, which, unlike comparing
+			// positions, rules out "x := func() { x := 1 }".
+			if found, _ := s.LookupParent(name, ident.Pos()); found != s {
+				continue
+			}
+		}
+
+		if _, ok := inner.(*types.TypeName); ok {
+			// A local type only hides another type; a type
+			// named like a variable is a matter of style.
+			if _, ok = obj.(*types.TypeName); ok && (s != types.Universe || c.checkPredeclared) {
+				return obj
+			}
+			return nil
+		}
+		if s == types.Universe && c.checkPredeclared {
+			return obj
+		}
+		switch obj.(type) {
+		case *types.Var, *types.PkgName:
+			return obj
+		case *types.Const:
+			if s != types.Universe || c.checkNonVarOuters {
+				return obj
+			}
+		case *types.Func:
+			if c.checkNonVarOuters || c.checkFuncOuters {
+				return obj
+			}
+		case *types.Builtin:
+			if c.checkNonVarOuters {
+				return obj
+			}
+		}
+		// The nearest declaration is not a variable, so ident
+		// does not shadow any variable further out.
+		return nil
+	}
+
+	return nil
+}
+
+// describe names outer for a message, e.g. `the constant "max"
+// declared at a.go:3:7` or `the builtin function "len"`.
+func (c *checker) describe(outer types.Object) string {
+	var what string
+	switch outer.(type) {
+	case *types.Const:
+		what = "constant"
+	case *types.Func:
+		what = "function"
+	case *types.Builtin:
+		what = "builtin function"
+	case *types.PkgName:
+		what = "imported package"
+	case *types.TypeName:
+		what = "type"
+	case *types.Nil:
+		what = "identifier"
+	default:
+		what = "variable"
+	}
+	if outer.Parent() == types.Universe {
+		if what != "builtin function" {
+			what = "predeclared " + what
+		}
+		return fmt.Sprintf("the %s %q", what, outer.Name())
+	}
+	return fmt.Sprintf("the %s %q declared at %s", what, outer.Name(), c.shortPos(outer.Pos()))
+}
+
+// testingParamType returns "*testing.T", "*testing.B" or "*testing.F" when
+// outer is a parameter of that type belonging to a function enclosing n.
+// An empty string is returned otherwise.
+func testingParamType(outer types.Object, n ast.Node, parent ancestors, info *types.Info) string {
+	ptr, ok := outer.Type().(*types.Pointer)
+	if !ok {
+		return ""
+	}
+	named, ok := types.Unalias(ptr.Elem()).(*types.Named)
+	if !ok {
+		return ""
+	}
+	tn := named.Obj()
+	if tn.Pkg() == nil || tn.Pkg().Path() != "testing" {
+		return ""
+	}
+	switch tn.Name() {
+	case "T", "B", "F":
+	default:
+		return ""
+	}
+	if !isParam(outer, n, parent, info) {
+		return ""
+	}
+
+	return "*testing." + tn.Name()
+}
+
+// isFuncVarShadow reports whether outer is a function-typed variable
+// that is used again after the top-level statement containing as. This
+// is the classic broken dispatch selection, where the inner assignment
+// was meant to replace the outer handler.
+func isFuncVarShadow(outer types.Object, as *ast.AssignStmt, parent ancestors, info *types.Info) bool {
+	if _, ok := outer.Type().Underlying().(*types.Signature); !ok {
+		return false
+	}
+
+	stmt := findOwningStmt(as, parent)
+	funcBody := ownerFuncBody(outer, as, parent)
+	if stmt == nil || funcBody == nil {
+		return false
+	}
+
+	return outerUsedLater(outer, stmt, funcBody, info)
+}
+
+// isPoolShadow reports whether outer, declared in file, holds a value
+// taken from a sync.Pool and ident, declared by as, replaces it with a
+// freshly allocated one: the pooled value is then never used or put
+// back, and the allocation the pool was meant to save happens anyway.
+func isPoolShadow(outer types.Object, ident *ast.Ident, as *ast.AssignStmt, file *ast.File, info *types.Info) bool {
+	if file == nil || len(as.Lhs) != len(as.Rhs) {
+		return false
+	}
+
+	var fresh bool
+	for i, lhs := range as.Lhs {
+		if lhs == ident {
+			fresh = isAllocation(as.Rhs[i], info)
+		}
+	}
+	if !fresh {
+		return false
+	}
+
+	pooled := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if pooled || n == nil || outer.Pos() < n.Pos() || outer.Pos() >= n.End() {
+			return false
+		}
+		var lhs []*ast.Ident
+		var rhs []ast.Expr
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, e := range n.Lhs {
+				id, _ := e.(*ast.Ident)
+				lhs = append(lhs, id)
+			}
+			rhs = n.Rhs
+		case *ast.ValueSpec:
+			lhs, rhs = n.Names, n.Values
+		default:
+			return true
+		}
+		if len(lhs) == len(rhs) {
+			for i, id := range lhs {
+				if id != nil && info.Defs[id] == outer {
+					pooled = isPoolGet(rhs[i], info)
+				}
+			}
+		}
+		return false
+	})
+
+	return pooled
+}
+
+// errorChainCheck returns "errors.As" or "errors.Is" if inner, declared
+// by as, is passed to either within its scope: the if or switch whose
+// init statement as is, or else the enclosing block. It returns ""
+// otherwise.
+func errorChainCheck(inner types.Object, as *ast.AssignStmt, parent ancestors, info *types.Info) (found string) {
+	var scope ast.Node = findEnclosingBlock(as, parent)
+	switch s := parent.of(as).(type) {
+	case *ast.IfStmt:
+		if s.Init == as {
+			scope = s
+		}
+	case *ast.SwitchStmt:
+		if s.Init == as {
+			scope = s
+		}
+	}
+	if scope == nil {
+		return ""
+	}
+
+	ast.Inspect(scope, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || found != "" || len(call.Args) == 0 {
+			return found == ""
+		}
+		// typeutil.Callee panics without Types, which Check does
+		// not require, so match the selector by hand.
+		sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		fn, ok := info.Uses[sel.Sel].(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "errors" || fn.Name() != "As" && fn.Name() != "Is" {
+			return true
+		}
+		if id, ok := ast.Unparen(call.Args[0]).(*ast.Ident); ok && info.Uses[id] == inner {
+			found = "errors." + fn.Name()
+		}
+		return found == ""
+	})
+	return
+}
+
+// droppedError returns the return statement reading outer after the
+// statement holding the error shadow ident, declared by as, if ident
+// may hold an error, i.e. is not bound to nil, and nothing in its
+// scope reads it but blank assignments. It returns nil otherwise.
+func (c *checker) droppedError(ident *ast.Ident, inner, outer types.Object, as *ast.AssignStmt) *ast.ReturnStmt {
+	info := c.pass.TypesInfo
+	switch {
+	case len(as.Rhs) == 1 && isTypeExpr(as.Rhs[0], info):
+		// "var err error"
+		return nil
+	case len(as.Lhs) == len(as.Rhs):
+		for i, lhs := range as.Lhs {
+			if lhs == ident && isNil(as.Rhs[i], info) {
+				return nil
+			}
+		}
+	}
+
+	var scope *ast.BlockStmt
+	switch p := c.parent.of(as).(type) {
+	case *ast.IfStmt, *ast.SwitchStmt, *ast.ForStmt:
+		scope = &ast.BlockStmt{List: []ast.Stmt{p.(ast.Stmt)}}
+	default:
+		scope = findEnclosingBlock(as, c.parent)
+	}
+	if used, _ := innerUsed(inner, scope, info); used {
+		return nil
+	}
+
+	body := ownerFuncBody(outer, as, c.parent)
+	if body == nil {
+		return nil
+	}
+	var ret *ast.ReturnStmt
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if n.Pos() >= scope.End() && stmtUsesOuter(n, outer, info) {
+				ret = n
+			}
+		}
+		return ret == nil
+	})
+	return ret
+}
+
+// syncEffects describes, for each sync primitive, what goes wrong when
+// it is shadowed.
+var syncEffects = map[string]string{
+	"Mutex":     "locking it in the rest of the block does not exclude holders of the outer lock",
+	"RWMutex":   "locking it in the rest of the block does not exclude holders of the outer lock",
+	"WaitGroup": "Add, Done and Wait in the rest of the block count a different group than the outer one",
+	"Once":      "Do in the rest of the block does not share the outer's once-only guarantee",
+}
+
+// syncPrimitive returns the name, e.g. "*sync.Mutex", of the sync
+// primitive t is, or points to, along with what shadowing it breaks.
+// It returns empty strings for any other type.
+func syncPrimitive(t types.Type) (name, effect string) {
+	ptr := ""
+	if p, ok := t.(*types.Pointer); ok {
+		t, ptr = p.Elem(), "*"
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "sync" {
+		return "", ""
+	}
+	effect, ok = syncEffects[named.Obj().Name()]
+	if !ok {
+		return "", ""
+	}
+	return ptr + "sync." + named.Obj().Name(), effect
+}
+
+// isNil reports whether e denotes the predeclared nil, possibly
+// converted, as in "error(nil)".
+func isNil(e ast.Expr, info *types.Info) bool {
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		_, ok := info.Uses[e].(*types.Nil)
+		return ok
+	case *ast.CallExpr:
+		return len(e.Args) == 1 && isTypeExpr(e.Fun, info) && isNil(e.Args[0], info)
+	}
+	return false
+}
+
+// isTypeExpr reports whether e is a, possibly qualified, type name.
+func isTypeExpr(e ast.Expr, info *types.Info) bool {
+	var id *ast.Ident
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return false
+	}
+	_, ok := info.Uses[id].(*types.TypeName)
+	return ok
+}
+
+// elseIfLink returns the if statement whose init declares outer, named
+// name, if as is the init of the if in its else branch, as in
+//
+//	if err := a(); err != nil {
+//	} else if err := b(); err != nil {
+//
+// It returns nil otherwise.
+func elseIfLink(name string, outer types.Object, as ast.Stmt, parent ancestors, info *types.Info) *ast.IfStmt {
+	ifs, ok := parent.of(as).(*ast.IfStmt)
+	if !ok || ifs.Init != as {
+		return nil
+	}
+	prev, ok := parent.of(ifs).(*ast.IfStmt)
+	if !ok || prev.Else != ifs {
+		return nil
+	}
+	if id := initDecl(prev, name, info); id == nil || info.Defs[id] != outer {
+		return nil
+	}
+	return prev
+}
+
+// elseIfChain returns the identifiers declaring name in the inits of
+// the clauses following first in its else-if chain, up to the first
+// clause not declaring it.
+func elseIfChain(name string, first *ast.IfStmt, info *types.Info) (links []*ast.Ident) {
+	for ifs, ok := first.Else.(*ast.IfStmt); ok; ifs, ok = ifs.Else.(*ast.IfStmt) {
+		id := initDecl(ifs, name, info)
+		if id == nil {
+			break
+		}
+		links = append(links, id)
+	}
+	return
+}
+
+// initDecl returns the identifier declaring name by the init statement
+// of ifs, or nil.
+func initDecl(ifs *ast.IfStmt, name string, info *types.Info) *ast.Ident {
+	as, ok := ifs.Init.(*ast.AssignStmt)
+	if !ok || as.Tok != token.DEFINE {
+		return nil
+	}
+	for _, lhs := range as.Lhs {
+		if id, ok := lhs.(*ast.Ident); ok && id.Name == name && info.Defs[id] != nil {
+			return id
+		}
+	}
+	return nil
+}
+
+// siblingAssign returns a statement assigning to outer with "=" in a
+// branch of the if/else chain, switch or select holding as directly in
+// one of its other branches, as in
+//
+//	if ok {
+//		err = f()
+//	} else {
+//		err := g()
+//	}
+//
+// It returns nil if there is no such statement.
+func siblingAssign(outer types.Object, as *ast.AssignStmt, parent ancestors, info *types.Info) ast.Stmt {
+	own := parent.of(as)
+	var branches []ast.Node
+	switch p := own.(type) {
+	case *ast.BlockStmt:
+		ifs, ok := parent.of(p).(*ast.IfStmt)
+		if !ok {
+			return nil
+		}
+		for {
+			up, ok := parent.of(ifs).(*ast.IfStmt)
+			if !ok || up.Else != ifs {
+				break
+			}
+			ifs = up
+		}
+		for ifs != nil {
+			branches = append(branches, ifs.Body)
+			next, ok := ifs.Else.(*ast.IfStmt)
+			if !ok && ifs.Else != nil {
+				branches = append(branches, ifs.Else)
+			}
+			ifs = next
+		}
+	case *ast.CaseClause, *ast.CommClause:
+		body, ok := parent.of(p).(*ast.BlockStmt)
+		if !ok {
+			return nil
+		}
+		for _, clause := range body.List {
+			branches = append(branches, clause)
+		}
+	default:
+		return nil
+	}
+
+	for _, branch := range branches {
+		if branch == own {
+			continue
+		}
+		var list []ast.Stmt
+		switch b := branch.(type) {
+		case *ast.BlockStmt:
+			list = b.List
+		case *ast.CaseClause:
+			list = b.Body
+		case *ast.CommClause:
+			list = b.Body
+		}
+		for _, stmt := range list {
+			sib, ok := stmt.(*ast.AssignStmt)
+			if !ok || sib.Tok != token.ASSIGN {
+				continue
+			}
+			for _, lhs := range sib.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && info.Uses[id] == outer {
+					return sib
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// contextShadow returns the name of the function, such as
+// "context.WithTimeout", if ident, declared by as, is bound to the
+// context derived by a context.With* call and none of the variables of
+// as escapes the enclosing block: none is returned, sent on a channel
+// or assigned to a variable declared outside of the block. It returns
+// "" otherwise.
+func contextShadow(inner types.Object, ident *ast.Ident, as *ast.AssignStmt, parent ancestors, info *types.Info) string {
+	if len(as.Rhs) != 1 || len(as.Lhs) == 0 || as.Lhs[0] != ident {
+		return ""
+	}
+	call, ok := ast.Unparen(as.Rhs[0]).(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "context" || !strings.HasPrefix(fn.Name(), "With") {
+		return ""
+	}
+	block := findEnclosingBlock(as, parent)
+	if block == nil {
+		return ""
+	}
+
+	derived := map[types.Object]bool{inner: true}
+	for _, lhs := range as.Lhs[1:] {
+		if id, ok := lhs.(*ast.Ident); ok && info.Defs[id] != nil {
+			derived[info.Defs[id]] = true
+		}
+	}
+	mentions := func(exprs ...ast.Expr) (found bool) {
+		for _, e := range exprs {
+			ast.Inspect(e, func(n ast.Node) bool {
+				id, ok := n.(*ast.Ident)
+				found = found || ok && derived[info.Uses[id]]
+				return !found
+			})
+		}
+		return
+	}
+	outside := func(e ast.Expr) bool {
+		id, ok := ast.Unparen(e).(*ast.Ident)
+		obj := info.Uses[id]
+		return !ok || obj != nil && (obj.Pos() < block.Pos() || block.End() <= obj.Pos())
+	}
+
+	escapes := false
+	ast.Inspect(block, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ReturnStmt:
+			escapes = escapes || mentions(n.Results...)
+		case *ast.SendStmt:
+			escapes = escapes || mentions(n.Value)
+		case *ast.AssignStmt:
+			if n.Tok == token.ASSIGN && slices.ContainsFunc(n.Lhs, outside) {
+				escapes = escapes || mentions(n.Rhs...)
+			}
+		}
+		return !escapes
+	})
+	if escapes {
+		return ""
+	}
+	return "context." + fn.Name()
+}
+
+// isPoolGet reports whether e is a call to (*sync.Pool).Get, possibly
+// followed by a type assertion.
+func isPoolGet(e ast.Expr, info *types.Info) bool {
+	e = ast.Unparen(e)
+	if ta, ok := e.(*ast.TypeAssertExpr); ok {
+		e = ast.Unparen(ta.X)
+	}
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	// as in errorChainCheck, typeutil.Callee would need Types
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := info.Uses[sel.Sel].(*types.Func)
+	return ok && fn.FullName() == "(*sync.Pool).Get"
+}
+
+// isAllocation reports whether e allocates a new value: a call to make
+// or new, or a possibly address-taken composite literal.
+func isAllocation(e ast.Expr, info *types.Info) bool {
+	e = ast.Unparen(e)
+	if u, ok := e.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		e = ast.Unparen(u.X)
+	}
+	switch e := e.(type) {
+	case *ast.CompositeLit:
+		return true
+	case *ast.CallExpr:
+		if id, ok := ast.Unparen(e.Fun).(*ast.Ident); ok {
+			b, ok := info.Uses[id].(*types.Builtin)
+			return ok && (b.Name() == "make" || b.Name() == "new")
+		}
+	}
+	return false
+}
+
+// isAssertedValueShadow reports whether outer is the value bound by a
+// comma-ok type assertion in the init statement of an if whose body
+// contains as, i.e. "if v, ok := x.(T); ok { v := ... }", and whether
+// ident shadows it before the condition or body has read it.
+func isAssertedValueShadow(
+	outer types.Object,
+	ident *ast.Ident,
+	as *ast.AssignStmt,
+	parent ancestors,
+	info *types.Info,
+) bool {
+	for cur := ast.Node(as); cur != nil; cur = parent.of(cur) {
+		ifs, ok := parent.of(cur).(*ast.IfStmt)
+		if !ok || cur != ifs.Body {
+			continue
+		}
+		init, ok := ifs.Init.(*ast.AssignStmt)
+		if !ok || init.Tok != token.DEFINE || len(init.Lhs) != 2 || len(init.Rhs) != 1 {
+			continue
+		}
+		if _, ok = ast.Unparen(init.Rhs[0]).(*ast.TypeAssertExpr); !ok {
+			continue
+		}
+		if v, ok := init.Lhs[0].(*ast.Ident); !ok || info.Defs[v] != outer {
+			continue
+		}
+
+		read := false
+		ast.Inspect(ifs, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			// the shadowing statement itself may read it, as in
+			// "v := v.inner"
+			if ok && id.Pos() < as.End() && info.Uses[id] == outer {
+				read = true
+			}
+			return !read
+		})
+		return !read
+	}
+
+	return false
+}
 
-	insp.WithStack(nil, func(n ast.Node, push bool, stack []ast.Node) bool {
-		if push && len(stack) > 1 {
-			parent[n] = stack[len(stack)-2]
+// capturedByGoOrDefer reports whether inner is referenced, within block,
+// by a function literal launched with a go or defer statement, as in
+// the deliberate "err := f(); go func() { use(err) }()" idiom which
+// gives each goroutine a fresh variable.
+func capturedByGoOrDefer(inner types.Object, block *ast.BlockStmt, info *types.Info) (captured bool) {
+	ast.Inspect(block, func(n ast.Node) bool {
+		var call *ast.CallExpr
+		switch s := n.(type) {
+		case *ast.GoStmt:
+			call = s.Call
+		case *ast.DeferStmt:
+			call = s.Call
+		default:
+			return !captured
 		}
-		return true
+		if lit, ok := ast.Unparen(call.Fun).(*ast.FuncLit); ok {
+			captured = stmtUsesOuter(lit.Body, inner, info)
+		}
+		return !captured
 	})
-
-	return parent
+	return
 }
 
-func skipFile(pass *analysis.Pass, n ast.Node) (skip bool) {
-	if !ignoreTests {
-		pos := pass.Fset.Position(n.Pos())
-		skip = strings.HasSuffix(pos.Filename, "_test.go")
+// closureShadowingResult returns "deferred" or "go" when n lies directly
+// within a function literal called by a defer or go statement, and
+// outer is a named result of a function enclosing that literal, as in
+// "defer func() { err := recover() ... }()". An empty string is
+// returned otherwise.
+func closureShadowingResult(outer types.Object, n ast.Node, parent ancestors, info *types.Info) string {
+	lit, stmt := launchedClosure(n, parent)
+	if lit == nil {
+		return ""
 	}
-
-	return
+	if body, result := declaringFunc(outer, lit, parent, info); !result || body == lit.Body {
+		return ""
+	}
+	if _, ok := stmt.(*ast.GoStmt); ok {
+		return "go"
+	}
+	return "deferred"
 }
 
-func processAssign(pass *analysis.Pass, as *ast.AssignStmt, parent map[ast.Node]ast.Node) {
-	for _, lhs := range as.Lhs {
-		ident, ok := lhs.(*ast.Ident)
-		if !ok || ident.Name == "_" {
-			continue
-		}
+// isGoroutineShadow reports whether n lies directly within a function
+// literal started by a go statement, and outer, declared outside of it,
+// is used both by the launching function ahead of the go statement and
+// by the goroutine itself. The shadow then sits right where accesses
+// to outer from two goroutines meet.
+func isGoroutineShadow(outer types.Object, n ast.Node, parent ancestors, info *types.Info) bool {
+	lit, stmt := launchedClosure(n, parent)
+	if _, ok := stmt.(*ast.GoStmt); !ok || lit.Pos() <= outer.Pos() && outer.Pos() < lit.End() {
+		return false
+	}
+	body := ownerFuncBody(outer, stmt, parent)
+	if body == nil {
+		return false
+	}
 
-		obj := pass.TypesInfo.Defs[ident]
-		if obj == nil {
-			continue
+	var before, inside bool
+	ast.Inspect(body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && info.Uses[id] == outer {
+			before = before || id.Pos() < stmt.Pos()
+			inside = inside || lit.Pos() <= id.Pos() && id.Pos() < lit.End()
 		}
-		outer := findOuter(pass.TypesInfo, ident, obj)
-		if outer == nil {
-			continue
+		return !before || !inside
+	})
+	return before && inside
+}
+
+// capturedOuter returns the function literal directly enclosing n if
+// outer is a local variable of an enclosing function, declared outside
+// the literal, along with whether the literal refers to outer anywhere,
+// i.e. captures it. The literal is nil otherwise.
+func capturedOuter(outer types.Object, n ast.Node, parent ancestors, info *types.Info) (*ast.FuncLit, bool) {
+	var lit *ast.FuncLit
+	for cur := parent.of(n); cur != nil && lit == nil; cur = parent.of(cur) {
+		switch fn := cur.(type) {
+		case *ast.FuncLit:
+			lit = fn
+		case *ast.FuncDecl:
+			return nil, false
 		}
-		if shouldSkipShadow(pass, ident, outer, as, parent) {
-			continue
+	}
+	if lit == nil || lit.Pos() <= outer.Pos() && outer.Pos() < lit.End() {
+		return nil, false
+	}
+	for _, fn := range parent {
+		switch fn.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			if outer.Pos() < fn.Pos() || fn.End() <= outer.Pos() {
+				// package-level, not local
+				return nil, false
+			}
+			return lit, stmtUsesOuter(lit.Body, outer, info)
 		}
-		pass.Reportf(ident.Pos(),
-			"variable %q is redefined and shadows an outer %q",
-			ident.Name, ident.Name)
 	}
+	return nil, false
 }
 
-func shouldSkipShadow(
-	pass *analysis.Pass,
-	ident *ast.Ident,
-	outer types.Object,
-	as *ast.AssignStmt,
-	parent map[ast.Node]ast.Node,
-) (should bool) {
-	// nearest block (may be inner block, e.g., if body)
-	block := findEnclosingBlock(as, parent)
-	if block == nil {
-		return
+// launchedClosure returns the function literal directly enclosing n,
+// along with the defer or go statement calling it, as in "go func() {
+// ... }()". Both are nil if there is no such literal.
+func launchedClosure(n ast.Node, parent ancestors) (*ast.FuncLit, ast.Stmt) {
+	var lit *ast.FuncLit
+	for cur := parent.of(n); cur != nil && lit == nil; cur = parent.of(cur) {
+		switch fn := cur.(type) {
+		case *ast.FuncLit:
+			lit = fn
+		case *ast.FuncDecl:
+			return nil, nil
+		}
 	}
-
-	// immediate owning statement (may be the AssignStmt itself, or an ExprStmt, etc.)
-	stmt := findOwningStmt(as, parent)
-	if stmt == nil {
-		return
+	if lit == nil {
+		return nil, nil
 	}
 
-	// function body block and the top-level statement inside that function body
-	funcBody := findFuncBody(as, parent)
-	topStmt := stmt
-	if funcBody != nil {
-		topStmt = findTopLevelStmt(stmt, parent, funcBody)
+	call, ok := parent.of(lit).(*ast.CallExpr)
+	if !ok || call.Fun != lit {
+		return nil, nil
 	}
-
-	// Evaluate skip checks. For the checks that need the function-level
-	// context (dead-outer and guard-only), pass topStmt and funcBody.
-	for _, should = range []bool{
-		skipForShortIf(parent, as),
-		skipForSameLine(pass, ident, outer),
-		skipForLoopShadow(parent, stmt),
-		// use topStmt and funcBody for dead-outer detection
-		skipForDeadOuter(pass, outer, topStmt, funcBody),
-		skipForErrShadow(ident, outer),
-		// use topStmt and funcBody for guard-only detection
-		skipForGuardShadow(pass, outer, topStmt, funcBody),
-		skipForTableTests(as, parent, pass.TypesInfo),
-	} {
-		if should {
-			break
-		}
+	switch stmt := parent.of(call).(type) {
+	case *ast.DeferStmt:
+		return lit, stmt
+	case *ast.GoStmt:
+		return lit, stmt
 	}
-
-	return
+	return nil, nil
 }
 
-func skipForShortIf(parent map[ast.Node]ast.Node, as *ast.AssignStmt) bool {
-	_, ok := parent[as].(*ast.IfStmt)
-	return ok && allowShortIf
+// isParam reports whether outer is declared in the parameter list of
+// a FuncDecl or FuncLit enclosing n, or is the receiver of the former.
+func isParam(outer types.Object, n ast.Node, parent ancestors, info *types.Info) bool {
+	body, result := declaringFunc(outer, n, parent, info)
+	return body != nil && !result
 }
 
-func skipForSameLine(pass *analysis.Pass, ident *ast.Ident, outer types.Object) bool {
-	return pass.Fset.Position(ident.Pos()).Line ==
-		pass.Fset.Position(outer.Pos()).Line && allowSameLine
-}
+// declaringFunc returns the body of the FuncDecl or FuncLit enclosing n
+// whose signature declares outer, and whether outer is one of its named
+// results rather than a parameter or the receiver. The body is nil if
+// there is no such function.
+func declaringFunc(outer types.Object, n ast.Node, parent ancestors, info *types.Info) (body *ast.BlockStmt, result bool) {
+	declares := func(list *ast.FieldList) bool {
+		if list == nil {
+			return false
+		}
+		for _, field := range list.List {
+			for _, name := range field.Names {
+				if info.Defs[name] == outer {
+					return true
+				}
+			}
+		}
+		return false
+	}
 
-func skipForLoopShadow(parent map[ast.Node]ast.Node, stmt ast.Stmt) (ok bool) {
-	if allowLoopShadow {
-		if _, ok = parent[stmt].(*ast.ForStmt); ok {
-			return
+	for cur := n; cur != nil; cur = parent.of(cur) {
+		var ft *ast.FuncType
+		var recv *ast.FieldList
+		switch fn := cur.(type) {
+		case *ast.FuncDecl:
+			ft, body, recv = fn.Type, fn.Body, fn.Recv
+		case *ast.FuncLit:
+			ft, body = fn.Type, fn.Body
+		default:
+			continue
 		}
-		if _, ok = parent[stmt].(*ast.RangeStmt); ok {
-			return
+		if declares(recv) || declares(ft.Params) {
+			return body, false
+		}
+		if declares(ft.Results) {
+			return body, true
 		}
 	}
-	return
+	return nil, false
 }
 
-func skipForDeadOuter(
-	pass *analysis.Pass,
-	outer types.Object,
-	stmt ast.Stmt,
-	block *ast.BlockStmt,
-) (allow bool) {
-	if allowDeadOuter {
-		allow = !outerUsedLater(outer, stmt, block, pass.TypesInfo) && allowDeadOuter
+// deferredNamedResultUse returns the first defer statement in the body
+// of the function declaring outer as a named result whose call refers to
+// outer, either directly, by address or from a deferred closure. Such a
+// call is expected to observe the value the function returns, which a
+// shadow inside the body will not update. Nil is returned otherwise.
+func deferredNamedResultUse(outer types.Object, n ast.Node, parent ancestors, info *types.Info) (found *ast.DeferStmt) {
+	body, result := declaringFunc(outer, n, parent, info)
+	if body == nil || !result {
+		return nil
 	}
 
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.FuncLit:
+			// defers inside other closures belong to those closures
+			return false
+		case *ast.DeferStmt:
+			if stmtUsesOuter(&ast.ExprStmt{X: s.Call}, outer, info) {
+				found = s
+			}
+			return false
+		}
+		return found == nil
+	})
 	return
 }
 
-func skipForErrShadow(ident *ast.Ident, outer types.Object) (allow bool) {
-	if allowErrShadow {
-		allow = ident.Name == "err" && outer.Name() == "err"
+// findFuncBody walks parents until it finds the function body BlockStmt
+// (either from a FuncDecl or a FuncLit). Returns nil if not found. See
+// ownerFuncBody for the body that declares a given variable.
+func findFuncBody(n ast.Node, parent ancestors) *ast.BlockStmt {
+	for cur := n; cur != nil; cur = parent.of(cur) {
+		p := parent.of(cur)
+		switch fn := p.(type) {
+		case *ast.FuncDecl:
+			return fn.Body
+		case *ast.FuncLit:
+			return fn.Body
+		}
 	}
-	return
-}
-
-func skipForGuardShadow(pass *analysis.Pass, outer types.Object, stmt ast.Stmt, block *ast.BlockStmt) bool {
-	return isGuardClauseOnly(outer, stmt, block, pass.TypesInfo) && allowGuardShadow
-}
-
-func skipForTableTests(as *ast.AssignStmt, parent map[ast.Node]ast.Node, info *types.Info) bool {
-	return isTableTestPattern(as, parent, info) && allowTableTests
+	return nil
 }
 
-func findOuter(info *types.Info, ident *ast.Ident, inner types.Object) types.Object {
-	name := ident.Name
-	scope := inner.Parent()
-	if scope == nil {
-		return nil
+// ownerFuncBody returns the body of the innermost function enclosing n
+// that also encloses the declaration of outer, which is not the nearest
+// one when n lies in a closure and outer outside it. If outer is not
+// declared in any such function, the nearest function body is returned.
+func ownerFuncBody(outer types.Object, n ast.Node, parent ancestors) *ast.BlockStmt {
+	for cur := parent.of(n); cur != nil; cur = parent.of(cur) {
+		var body *ast.BlockStmt
+		switch fn := cur.(type) {
+		case *ast.FuncDecl:
+			body = fn.Body
+		case *ast.FuncLit:
+			body = fn.Body
+		default:
+			continue
+		}
+		if cur.Pos() <= outer.Pos() && outer.Pos() < cur.End() {
+			return body
+		}
 	}
+	return findFuncBody(n, parent)
+}
 
-	for s := scope.Parent(); s != nil; s = s.Parent() {
-		if obj := s.Lookup(name); obj != nil {
-			if v, ok := obj.(*types.Var); ok {
-				// Only treat it as an outer variable if
-				// it appears earlier in the file.
-				if v.Pos() < ident.Pos() {
-					return v
-				}
-			}
+// inLoop reports whether n lies within the body of a for or range
+// statement of its own function.
+func inLoop(n ast.Node, parent ancestors) bool {
+	for cur := parent.of(n); cur != nil; cur = parent.of(cur) {
+		switch cur.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			return true
+		case *ast.FuncDecl, *ast.FuncLit:
+			return false
 		}
 	}
-
-	return nil
+	return false
 }
 
-// findFuncBody walks parents until it finds the function body BlockStmt
-// (either from a FuncDecl or a FuncLit). Returns nil if not found.
-func findFuncBody(n ast.Node, parent map[ast.Node]ast.Node) *ast.BlockStmt {
-	for cur := n; cur != nil; cur = parent[cur] {
-		p := parent[cur]
-		switch fn := p.(type) {
+// outermostFuncBody returns the body of the top-level function (or
+// function literal outside any function) on the stack, which contains
+// every closure nested within it.
+func outermostFuncBody(parent ancestors) *ast.BlockStmt {
+	for _, n := range parent {
+		switch fn := n.(type) {
 		case *ast.FuncDecl:
 			return fn.Body
 		case *ast.FuncLit:
@@ -220,14 +2860,14 @@ func findFuncBody(n ast.Node, parent map[ast.Node]ast.Node) *ast.BlockStmt {
 
 // findTopLevelStmt returns the statement that is a direct child of block
 // and that is an ancestor of stmt. If none is found, returns stmt.
-func findTopLevelStmt(stmt ast.Stmt, parent map[ast.Node]ast.Node, block *ast.BlockStmt) ast.Stmt {
+func findTopLevelStmt(stmt ast.Stmt, parent ancestors, block *ast.BlockStmt) ast.Stmt {
 	if block == nil || stmt == nil {
 		return stmt
 	}
-	for cur := ast.Node(stmt); cur != nil; cur = parent[cur] {
+	for cur := ast.Node(stmt); cur != nil; cur = parent.of(cur) {
 		// If the parent of cur is the block, then cur is the direct child
 		// of block that contains stmt. Return cur if it is an ast.Stmt.
-		if parent[cur] == block {
+		if parent.of(cur) == block {
 			if s, ok := cur.(ast.Stmt); ok {
 				return s
 			}
@@ -237,9 +2877,9 @@ func findTopLevelStmt(stmt ast.Stmt, parent map[ast.Node]ast.Node, block *ast.Bl
 	return stmt
 }
 
-// findOwningStmt walks upward using the parent map until it finds an ast.Stmt.
-func findOwningStmt(n ast.Node, parent map[ast.Node]ast.Node) (s ast.Stmt) {
-	for cur := n; cur != nil; cur = parent[cur] {
+// findOwningStmt walks upward through its ancestors until it finds an ast.Stmt.
+func findOwningStmt(n ast.Node, parent ancestors) (s ast.Stmt) {
+	for cur := n; cur != nil; cur = parent.of(cur) {
 		var ok bool
 		if s, ok = cur.(ast.Stmt); ok {
 			break
@@ -249,8 +2889,8 @@ func findOwningStmt(n ast.Node, parent map[ast.Node]ast.Node) (s ast.Stmt) {
 }
 
 // findEnclosingBlock walks upward until it finds the nearest *ast.BlockStmt.
-func findEnclosingBlock(n ast.Node, parent map[ast.Node]ast.Node) (b *ast.BlockStmt) {
-	for cur := n; cur != nil; cur = parent[cur] {
+func findEnclosingBlock(n ast.Node, parent ancestors) (b *ast.BlockStmt) {
+	for cur := n; cur != nil; cur = parent.of(cur) {
 		var ok bool
 		if b, ok = cur.(*ast.BlockStmt); ok {
 			break
@@ -259,43 +2899,132 @@ func findEnclosingBlock(n ast.Node, parent map[ast.Node]ast.Node) (b *ast.BlockS
 	return
 }
 
-// outerUsedLater reports whether the OUTER object is used in any statement
-// after stmt within block.
-func outerUsedLater(outer types.Object, stmt ast.Stmt, block *ast.BlockStmt, info *types.Info) bool {
-	if block == nil {
-		return false
+// readAfterShadow returns the statement right after the one holding the
+// shadow n, in the block declaring outer, if it reads outer while the
+// statement holding n never assigns to it, as in
+//
+//	x := 0
+//	if ok {
+//		x := compute()
+//		_ = x
+//	}
+//	use(x)
+//
+// where the author most likely meant to update x. Nil is returned
+// otherwise.
+func readAfterShadow(outer types.Object, n ast.Node, parent ancestors, info *types.Info) ast.Stmt {
+	if _, ok := parent.of(n).(*ast.RangeStmt); ok {
+		// "for i = range" would rarely be what was meant
+		return nil
 	}
+	in := func(n ast.Node) bool { return n.Pos() <= outer.Pos() && outer.Pos() < n.End() }
+	for cur := n; ; cur = parent.of(cur) {
+		block, ok := parent.of(cur).(*ast.BlockStmt)
+		if !ok {
+			switch parent.of(cur).(type) {
+			case nil, *ast.FuncDecl, *ast.FuncLit:
+				return nil
+			}
+			continue
+		}
+		var fn ast.Node
+		switch f := parent.of(block).(type) {
+		case *ast.FuncDecl:
+			fn = f.Type
+		case *ast.FuncLit:
+			fn = f.Type
+		}
+		if !in(block) && (fn == nil || !in(fn)) {
+			continue
+		}
 
-	idx := -1
-	for i, s := range block.List {
-		if s == stmt {
-			idx = i
-			break
+		stmt, ok := cur.(ast.Stmt)
+		if !ok || stmt == n {
+			return nil
+		}
+		i := slices.Index(block.List, stmt)
+		if i < 0 || i+1 == len(block.List) {
+			return nil
+		}
+		if assignsTo(stmt, outer, info) {
+			return nil
+		}
+		next := block.List[i+1]
+		if used, _ := innerUsed(outer, &ast.BlockStmt{List: []ast.Stmt{next}}, info); !used {
+			return nil
 		}
+		return next
 	}
-	if idx == -1 {
+}
+
+// assignsTo reports whether n assigns to obj anywhere, by =, an
+// assignment operator such as +=, or ++ and --.
+func assignsTo(n ast.Node, obj types.Object, info *types.Info) (found bool) {
+	is := func(e ast.Expr) bool {
+		id, ok := ast.Unparen(e).(*ast.Ident)
+		return ok && info.Uses[id] == obj
+	}
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE {
+				found = found || slices.ContainsFunc(n.Lhs, is)
+			}
+		case *ast.IncDecStmt:
+			found = found || is(n.X)
+		}
+		return !found
+	})
+	return
+}
+
+// outerUsedLater reports whether the OUTER object is used anywhere in
+// body lexically after stmt, including within nested blocks and
+// function literals. Uses inside the scope of the shadow cannot refer
+// to outer, so any use past the end of stmt is a genuine later read.
+// So is a use between stmt and the label of a goto following it, which
+// may run again after the jump back.
+func outerUsedLater(outer types.Object, stmt ast.Stmt, body *ast.BlockStmt, info *types.Info) bool {
+	if body == nil || stmt == nil {
 		return false
 	}
 
-	for _, later := range block.List[idx+1:] {
-		found := false
-		ast.Inspect(later, func(n ast.Node) bool {
-			id, ok := n.(*ast.Ident)
-			if ok && info.Uses[id] == outer {
-				found = true
+	usedIn := func(from, to token.Pos) (found bool) {
+		ast.Inspect(body, func(n ast.Node) bool {
+			if found || n == nil || n.End() <= from || n.Pos() >= to {
 				return false
 			}
-			return true
+			if id, ok := n.(*ast.Ident); ok && id.Pos() >= from && info.Uses[id] == outer {
+				found = true
+			}
+			return !found
 		})
-		if found {
-			return true
-		}
+		return
+	}
+	if usedIn(stmt.End(), body.End()) {
+		return true
 	}
 
-	return false
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		br, ok := n.(*ast.BranchStmt)
+		if found || !ok || br.Tok != token.GOTO || br.Pos() <= stmt.Pos() {
+			return !found
+		}
+		if label := info.Uses[br.Label]; label != nil && label.Pos() < stmt.Pos() {
+			found = usedIn(label.Pos(), stmt.Pos())
+		}
+		return !found
+	})
+
+	return found
 }
 
-func isTableTestPattern(as *ast.AssignStmt, parent map[ast.Node]ast.Node, info *types.Info) bool {
+// isTableTestPattern reports whether as is "tt := tt" directly within
+// the body of a range statement whose value variable is the tt on the
+// right. The name on the left must match unless renames is set, which
+// also accepts "tc := tt".
+func isTableTestPattern(as *ast.AssignStmt, parent ancestors, info *types.Info, renames bool) bool {
 	// Must be a := with exactly one LHS and one RHS
 	if len(as.Lhs) != 1 || len(as.Rhs) != 1 {
 		return false
@@ -310,39 +3039,32 @@ func isTableTestPattern(as *ast.AssignStmt, parent map[ast.Node]ast.Node, info *
 		return false
 	}
 
-	// Must be inside a RangeStmt
-	rng, ok := parent[as].(*ast.RangeStmt)
-	if !ok {
-		return false
-	}
-
-	// Range must bind an identifier (e.g., "tt")
-	rangeIdent, ok := rng.Value.(*ast.Ident)
+	// Must be a statement of the body of a RangeStmt
+	body, ok := parent.of(as).(*ast.BlockStmt)
 	if !ok {
 		return false
 	}
-
-	// LHS must match the range variable name
-	if identLHS.Name != rangeIdent.Name {
-		return false
-	}
-
-	// RHS must match the range variable name
-	if identRHS.Name != rangeIdent.Name {
+	rng, ok := parent.of(body).(*ast.RangeStmt)
+	if !ok || rng.Body != body {
 		return false
 	}
 
-	// Must refer to the same object
-	objRange := info.Defs[rangeIdent]
+	// Must copy a variable bound by the range clause, be it the value
+	// (as in "for _, tt := range tests") or the key (as in "for tt :=
+	// range byCase" over a map, channel or iterator).
 	objLHS := info.Defs[identLHS]
 	objRHS := info.Uses[identRHS]
-
-	if objRange == nil || objLHS == nil || objRHS == nil {
+	if objLHS == nil || objRHS == nil {
 		return false
 	}
+	for _, e := range []ast.Expr{rng.Key, rng.Value} {
+		if rangeIdent, ok := e.(*ast.Ident); ok && info.Defs[rangeIdent] == objRHS {
+			// LHS must match the range variable name
+			return renames || identLHS.Name == rangeIdent.Name
+		}
+	}
 
-	// Pattern is: tt := tt inside a range over tests
-	return objRange == objRHS
+	return false
 }
 
 func isGuardClauseOnly(outer types.Object, stmt ast.Stmt, block *ast.BlockStmt, info *types.Info) bool {
@@ -365,6 +3087,20 @@ func isGuardClauseOnly(outer types.Object, stmt ast.Stmt, block *ast.BlockStmt,
 	return true
 }
 
+// usedBefore reports whether any statement of block ahead of stmt
+// uses outer.
+func usedBefore(outer types.Object, stmt ast.Stmt, block *ast.BlockStmt, info *types.Info) bool {
+	for _, s := range block.List {
+		if s == stmt {
+			break
+		}
+		if stmtUsesOuter(s, outer, info) {
+			return true
+		}
+	}
+	return false
+}
+
 func stmtUsesOuter(s ast.Stmt, outer types.Object, info *types.Info) bool {
 	used := false
 	ast.Inspect(s, func(n ast.Node) bool {
@@ -389,7 +3125,7 @@ func isValidGuardIf(s ast.Stmt, outer types.Object, info *types.Info) bool {
 	if !condUsesOuterOnly(ifs.Cond, outer, info) {
 		return false
 	}
-	return hasValidGuardBody(ifs.Body, outer, info)
+	return hasValidGuardBody(ifs.Body)
 }
 
 func condUsesOuterOnly(cond ast.Expr, outer types.Object, info *types.Info) bool {
@@ -405,46 +3141,420 @@ func condUsesOuterOnly(cond ast.Expr, outer types.Object, info *types.Info) bool
 	return found
 }
 
-func hasValidGuardBody(body *ast.BlockStmt, outer types.Object, info *types.Info) bool {
-	if len(body.List) != 1 {
+// hasValidGuardBody reports whether body exits the enclosing function
+// or loop: its last statement must be a return or branch statement,
+// which may be preceded by anything else, such as logging the error.
+func hasValidGuardBody(body *ast.BlockStmt) bool {
+	if len(body.List) == 0 {
 		return false
 	}
 
-	switch b := body.List[0].(type) {
+	switch body.List[len(body.List)-1].(type) {
 	case *ast.ReturnStmt, *ast.BranchStmt:
-		return !stmtUsesOuter(b, outer, info)
+		return true
 	default:
 		return false
 	}
 }
 
-// flag vars
-var (
+// settings holds the allow-* and related toggles in effect for
+// a single package.
+type settings struct {
 	ignoreTests,
-	allowShortIf,
+	ignoreGenerated,
+	includeVendor,
+	skipInitFuncs,
+	allowShortInit,
 	allowSameLine,
 	allowDeadOuter,
 	allowErrShadow,
 	allowLoopShadow,
+	allowForInit,
+	allowOkShadow,
 	allowTableTests,
-	allowGuardShadow bool
+	allowGuardShadow,
+	includePackageScope,
+	clusterByOuter,
+	warnFuncVarShadow,
+	checkTypeSwitch,
+	skipCgo,
+	allowCaptureShadow,
+	allowImportShadow,
+	allowGoroutineShadow,
+	allowConstShadow,
+	allowElseIfChain,
+	allowRebind,
+	warnLabelNameCollision,
+	warnPoolShadow,
+	warnContextShadow,
+	warnDroppedError,
+	checkBranchConsistency,
+	checkClosureParams,
+	ssa,
+	warnGoroutineShadow,
+	warnErrTreadmill,
+	checkNamedReturns,
+	checkDeferredReturnShadow,
+	checkErrorChain,
+	checkSyncShadow,
+	paramsStrict,
+	warnRepeatedBlockDecl,
+	checkSelect,
+	checkVarDecls,
+	checkTypeDecls,
+	warnTypeChange,
+	checkNonVarOuters,
+	checkFuncOuters,
+	checkPredeclared,
+	checkRedundantLoopCopy,
+	suggestReuse,
+	summary,
+	explain,
+	strict,
+	lenient,
+	warnUnusedInner bool
+	warnReadAfterShadow bool
+	tableTestRenames    bool
+	errNamePattern      pattern
+	messageTemplate     messageTemplate
+	allowNamePrefix,
+	allowNameSuffix string
+	allowNames,
+	tableTestFuncs,
+	excludeFuncs nameSet
+	maxRedefs,
+	minScopeDepth,
+	concurrency int
+}
+
+// pattern is a regular expression, settable as a flag value.
+type pattern struct {
+	*regexp.Regexp
+}
+
+func (p pattern) String() string {
+	if p.Regexp == nil {
+		return ""
+	}
+	return p.Regexp.String()
+}
+
+func (p *pattern) Set(value string) (err error) {
+	p.Regexp, err = regexp.Compile(value)
+	return
+}
+
+// messageData holds the fields available to -message-template.
+type messageData struct {
+	Inner    string // name of the inner variable
+	Outer    string // name of the outer variable
+	OuterPos string // file:line:column of the outer variable
+	Category string // redef.* code of the finding
+	Message  string // the message redef would report otherwise
+}
+
+// messageTemplate is a text/template for diagnostic messages, settable
+// as a flag value. An invalid template leaves the default messages in
+// place.
+type messageTemplate struct {
+	*template.Template
+	text string
+}
+
+func (t messageTemplate) String() string {
+	return t.text
+}
+
+func (t *messageTemplate) Set(value string) error {
+	t.Template, t.text = nil, ""
+	if value == "" {
+		return nil
+	}
+
+	// Execute it once, so that references to unknown fields fail
+	// here rather than on the first finding.
+	tmpl, err := template.New("message").Parse(value)
+	if err == nil {
+		err = tmpl.Execute(io.Discard, messageData{})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "redef: ignoring -message-template: %v\n", err)
+		return nil
+	}
+	t.Template, t.text = tmpl, value
+	return nil
+}
+
+// render executes t with data, falling back to data.Message should
+// that fail.
+func (t messageTemplate) render(data messageData) string {
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return data.Message
+	}
+	return sb.String()
+}
+
+// nameSet is a set of identifiers, settable as a comma-separated
+// flag value.
+type nameSet map[string]struct{}
+
+func (ns nameSet) has(name string) (ok bool) {
+	_, ok = ns[name]
+	return
+}
+
+// match reports whether name matches any of the path.Match patterns
+// in ns.
+func (ns nameSet) match(name string) bool {
+	for pattern := range ns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// globPrefix marks an entry of -exclude-funcs as a path.Match pattern
+// rather than a name, since "*" is also part of pointer receivers.
+const globPrefix = "glob:"
+
+// matchFunc reports whether name is in ns, or matches one of the
+// path.Match patterns marked with globPrefix in ns.
+func (ns nameSet) matchFunc(name string) bool {
+	if ns.has(name) {
+		return true
+	}
+	for pattern := range ns {
+		if glob, ok := strings.CutPrefix(pattern, globPrefix); ok {
+			if ok, _ = path.Match(glob, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (ns nameSet) String() string {
+	names := make([]string, 0, len(ns))
+	for name := range ns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (ns *nameSet) Set(value string) error {
+	set := make(nameSet)
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			set[name] = struct{}{}
+		}
+	}
+	*ns = set
+	return nil
+}
+
+// toggles maps each allow-* flag name to its field within s. These
+// are the names accepted as keys by the -config file, besides the
+// aliases in toggleAliases.
+func (s *settings) toggles() map[string]*bool {
+	return map[string]*bool{
+		"allow-short-init":       &s.allowShortInit,
+		"allow-same-line":        &s.allowSameLine,
+		"allow-dead-outer":       &s.allowDeadOuter,
+		"allow-err-shadow":       &s.allowErrShadow,
+		"allow-ok-shadow":        &s.allowOkShadow,
+		"allow-loop-shadow":      &s.allowLoopShadow,
+		"allow-for-init":         &s.allowForInit,
+		"allow-table-tests":      &s.allowTableTests,
+		"allow-guard-shadow":     &s.allowGuardShadow,
+		"allow-capture-shadow":   &s.allowCaptureShadow,
+		"allow-import-shadow":    &s.allowImportShadow,
+		"allow-goroutine-shadow": &s.allowGoroutineShadow,
+		"allow-const-shadow":     &s.allowConstShadow,
+		"allow-else-if-chain":    &s.allowElseIfChain,
+		"allow-rebind":           &s.allowRebind,
+	}
+}
+
+// toggleAliases maps deprecated allow-* flag names to their current
+// names.
+var toggleAliases = map[string]string{
+	"allow-short-if": "allow-short-init",
+}
+
+// enabledRules returns the sorted names of the suppression rules
+// turned on in s.
+func (s *settings) enabledRules() (rules []string) {
+	for name, on := range s.toggles() {
+		if *on {
+			rules = append(rules, name)
+		}
+	}
+	if len(s.allowNames) > 0 {
+		rules = append(rules, "allow-names")
+	}
+	if s.allowNamePrefix != "" {
+		rules = append(rules, "allow-name-prefix")
+	}
+	if s.allowNameSuffix != "" {
+		rules = append(rules, "allow-name-suffix")
+	}
+	if s.minScopeDepth > 0 {
+		rules = append(rules, "min-scope-depth")
+	}
+	if s.ssa {
+		rules = append(rules, "ssa")
+	}
+	sort.Strings(rules)
+
+	return
+}
+
+// flag vars
+var (
+	flags        settings
+	configPath   string
+	baselinePath string
 )
 
 func init() {
-	Analyzer.Flags.BoolVar(&allowErrShadow, "allow-err-shadow", false,
-		"Allow shadowing when both inner and outer variables are named err")
-	Analyzer.Flags.BoolVar(&allowGuardShadow, "allow-guard-shadow", false,
+	Analyzer.Flags.BoolVar(&flags.allowErrShadow, "allow-err-shadow", false,
+		"Allow shadowing when both inner and outer variables are error variables: both names match -err-name-pattern, or both types implement error")
+	flags.errNamePattern.Set(defaultErrNamePattern)
+	Analyzer.Flags.Var(&flags.errNamePattern, "err-name-pattern",
+		"Regular expression for names that -allow-err-shadow treats as error variables, besides any variable of error type")
+	Analyzer.Flags.BoolVar(&flags.allowGuardShadow, "allow-guard-shadow", false,
 		"Allow shadowing when the outer variable is only used in guard clauses")
-	Analyzer.Flags.BoolVar(&ignoreTests, "ignore-tests", false,
+	Analyzer.Flags.BoolVar(&flags.ignoreTests, "ignore-tests", false,
 		"Avoid checking any _test.go files")
-	Analyzer.Flags.BoolVar(&allowDeadOuter, "allow-dead-outer", false,
+	Analyzer.Flags.BoolVar(&flags.includeVendor, "include-vendor", false,
+		"Also check files in vendor directories and the module cache")
+	Analyzer.Flags.BoolVar(&flags.ignoreGenerated, "ignore-generated", false,
+		"Avoid checking files marked with a \"// Code generated ... DO NOT EDIT.\" comment")
+	Analyzer.Flags.BoolVar(&flags.allowDeadOuter, "allow-dead-outer", false,
 		"Allow shadowing when the outer variable is never used again")
-	Analyzer.Flags.BoolVar(&allowShortIf, "allow-short-if", false,
-		"Allow shadowing inside short-if statements")
-	Analyzer.Flags.BoolVar(&allowSameLine, "allow-same-line", false,
+	Analyzer.Flags.BoolVar(&flags.allowShortInit, "allow-short-init", false,
+		"Allow shadowing in the init statement of an if, for, switch or type switch")
+	Analyzer.Flags.BoolVar(&flags.allowShortInit, "allow-short-if", false,
+		"Deprecated alias for -allow-short-init")
+	Analyzer.Flags.BoolVar(&flags.allowSameLine, "allow-same-line", false,
 		"Allow shadowing when inner and outer appear on the same line")
-	Analyzer.Flags.BoolVar(&allowLoopShadow, "allow-loop-shadow", false,
-		"Allow shadowing inside for/range loops")
-	Analyzer.Flags.BoolVar(&allowTableTests, "allow-table-tests", false,
+	Analyzer.Flags.BoolVar(&flags.allowLoopShadow, "allow-loop-shadow", false,
+		"Allow shadowing inside for/range loop bodies and by range keys and values")
+	Analyzer.Flags.BoolVar(&flags.allowOkShadow, "allow-ok-shadow", false,
+		"Allow shadowing by the ok of a comma-ok form, as in v, ok := m[k]")
+	Analyzer.Flags.BoolVar(&flags.allowForInit, "allow-for-init", false,
+		"Allow shadowing in the init statement of a for loop, as in for i := 0; ...")
+	Analyzer.Flags.BoolVar(&flags.allowTableTests, "allow-table-tests", false,
 		"Allow shadowing in table-driven tests")
+	Analyzer.Flags.Var(&flags.tableTestFuncs, "table-test-funcs",
+		"Comma-separated name patterns of functions outside _test.go files that hold table tests")
+	Analyzer.Flags.BoolVar(&flags.tableTestRenames, "table-test-renames", false,
+		"Also treat tc := tt copies of a table test's range variable as table tests")
+	Analyzer.Flags.BoolVar(&flags.includePackageScope, "include-package-scope", false,
+		"Report shadowing of package-level variables declared anywhere in the package")
+	Analyzer.Flags.BoolVar(&flags.clusterByOuter, "cluster-by-outer", false,
+		"Emit one diagnostic per shadowed variable, listing each shadowing site")
+	Analyzer.Flags.BoolVar(&flags.summary, "summary", false,
+		"Emit one aggregated diagnostic per function instead of one per shadow")
+	Analyzer.Flags.BoolVar(&flags.warnFuncVarShadow, "warn-func-var-shadow", false,
+		"Warn when a function-typed variable is shadowed but still used afterwards")
+	Analyzer.Flags.BoolVar(&flags.checkTypeSwitch, "check-type-switch", true,
+		"Report type switch guards (v := x.(type)) that shadow an outer variable")
+	Analyzer.Flags.BoolVar(&flags.checkSelect, "check-select", true,
+		"Report select cases (case v := <-ch) that shadow an outer variable")
+	Analyzer.Flags.BoolVar(&flags.checkVarDecls, "check-var-decls", true,
+		"Report local var declarations (var x T) that shadow an outer variable")
+	Analyzer.Flags.BoolVar(&flags.checkTypeDecls, "check-type-decls", true,
+		"Report local type declarations (type T ...) that hide an outer type")
+	Analyzer.Flags.BoolVar(&flags.skipCgo, "skip-cgo", false,
+		"Skip files importing \"C\" and identifiers synthesized by cgo")
+	Analyzer.Flags.Var(&flags.allowNames, "allow-names",
+		"Comma-separated list of variable names that may be shadowed freely")
+	Analyzer.Flags.StringVar(&flags.allowNamePrefix, "allow-name-prefix", "",
+		"Allow shadowing by variables whose names start with this marker, e.g. _")
+	Analyzer.Flags.StringVar(&flags.allowNameSuffix, "allow-name-suffix", "",
+		"Allow shadowing by variables whose names end with this marker, e.g. Shadow")
+	Analyzer.Flags.BoolVar(&flags.allowCaptureShadow, "allow-capture-shadow", false,
+		"Allow shadowing when the inner variable is captured by a go or defer closure")
+	Analyzer.Flags.BoolVar(&flags.allowImportShadow, "allow-import-shadow", false,
+		"Allow shadowing of imported package names")
+	Analyzer.Flags.BoolVar(&flags.allowRebind, "allow-rebind", false,
+		"Allow shadowing by a variable derived from the outer one, as in s := strings.TrimSpace(s)")
+	Analyzer.Flags.BoolVar(&flags.allowElseIfChain, "allow-else-if-chain", false,
+		"Allow redeclaring a variable in the init of each clause of an else-if chain")
+	Analyzer.Flags.BoolVar(&flags.allowConstShadow, "allow-const-shadow", false,
+		"Allow shadowing of constants, such as maxRetries := n")
+	Analyzer.Flags.BoolVar(&flags.allowGoroutineShadow, "allow-goroutine-shadow", false,
+		"Allow shadowing of enclosing-function variables inside go closures")
+	Analyzer.Flags.BoolVar(&flags.warnLabelNameCollision, "warn-label-name-collision", false,
+		"Warn when a variable declared with := is named like an enclosing label")
+	Analyzer.Flags.BoolVar(&flags.warnGoroutineShadow, "warn-goroutine-shadow", false,
+		"Warn when a goroutine shadows a variable that both it and the function starting it use")
+	Analyzer.Flags.BoolVar(&flags.warnErrTreadmill, "warn-err-treadmill", false,
+		"Warn when more than two sibling if statements shadow the same error variable in their init")
+	Analyzer.Flags.BoolVar(&flags.warnPoolShadow, "warn-pool-shadow", false,
+		"Warn when a value taken from a sync.Pool is shadowed by a fresh allocation")
+	Analyzer.Flags.BoolVar(&flags.ssa, "ssa", false,
+		"Build the package in SSA form to report shadows whose write is lost as redef.lost-write, and drop those after which the outer is never read")
+	Analyzer.Flags.BoolVar(&flags.checkClosureParams, "check-closure-params", false,
+		"Also report function literal parameters, as in func(err error), that shadow an enclosing variable")
+	Analyzer.Flags.BoolVar(&flags.checkBranchConsistency, "check-branch-consistency", false,
+		"Report a := in one branch of an if/else, switch or select while a sibling branch assigns the same name with =, regardless of any allow-* rule")
+	Analyzer.Flags.BoolVar(&flags.warnDroppedError, "warn-dropped-error", false,
+		"Warn when an err shadow is never checked or returned while the outer err is returned later")
+	Analyzer.Flags.BoolVar(&flags.warnContextShadow, "warn-context-shadow", false,
+		"Warn when a context derived by context.With* shadows an outer one and never leaves its block")
+	Analyzer.Flags.BoolVar(&flags.checkDeferredReturnShadow, "check-deferred-return-shadow", true,
+		"Report variables in deferred or go closures that shadow a named result of the enclosing function")
+	Analyzer.Flags.BoolVar(&flags.checkErrorChain, "check-error-chain", true,
+		"Report err shadows inspected with errors.As or errors.Is, regardless of any allow-* rule")
+	Analyzer.Flags.BoolVar(&flags.checkSyncShadow, "check-sync-shadow", true,
+		"Report shadows of sync.Mutex, sync.RWMutex, sync.WaitGroup and sync.Once variables, regardless of any allow-* rule")
+	Analyzer.Flags.BoolVar(&flags.checkNamedReturns, "check-named-returns", false,
+		"Report shadows of named return values as such, regardless of any allow-* rule")
+	Analyzer.Flags.BoolVar(&flags.paramsStrict, "params-strict", false,
+		"Report shadows of function parameters and method receivers regardless of any allow-* rule")
+	Analyzer.Flags.BoolVar(&flags.warnRepeatedBlockDecl, "warn-repeated-block-decl", false,
+		"Warn when a name is declared with := in several separate blocks of a function")
+	Analyzer.Flags.BoolVar(&flags.warnTypeChange, "warn-type-change", false,
+		"Report shadows whose type differs from the outer variable's regardless of any allow-* rule; without it, they are still classified as redef.type-change")
+	Analyzer.Flags.BoolVar(&flags.checkNonVarOuters, "check-nonvar-outers", false,
+		"Also report variables shadowing a function or builtin")
+	Analyzer.Flags.BoolVar(&flags.checkFuncOuters, "check-func-outers", false,
+		"Also report variables shadowing a package-level function, as in list := list()")
+	Analyzer.Flags.BoolVar(&flags.checkPredeclared, "check-predeclared", false,
+		"Also report variables hiding a predeclared identifier, such as len, new, min, error or nil")
+	Analyzer.Flags.BoolVar(&flags.checkRedundantLoopCopy, "check-redundant-loopcopy", false,
+		"Report v := v copies of a loop variable, which are unnecessary as of Go 1.22")
+	Analyzer.Flags.BoolVar(&flags.suggestReuse, "suggest-reuse", false,
+		"Suggest assigning with = instead of := when every shadowed variable has the same type as its shadow")
+	Analyzer.Flags.BoolVar(&flags.warnUnusedInner, "warn-unused-inner", false,
+		"Note when the shadowing variable itself is never read, besides blank assignments and plain writes")
+	Analyzer.Flags.BoolVar(&flags.warnReadAfterShadow, "warn-read-after-shadow", false,
+		"Note when the outer variable is read right after the block holding the shadow, which never assigns to it")
+	Analyzer.Flags.BoolVar(&flags.skipInitFuncs, "skip-init-funcs", false,
+		"Avoid checking package init functions")
+	Analyzer.Flags.Var(&flags.excludeFuncs, "exclude-funcs",
+		"Comma-separated functions not to check, such as (*Server).Handle or pkg.Init, matched exactly; prefix a path.Match pattern with glob:, as in glob:pkg.Test*, to match several")
+	Analyzer.Flags.IntVar(&flags.maxRedefs, "max-redefs", 0,
+		"Tolerate this many shadows of each variable per function, reporting only the rest")
+	Analyzer.Flags.IntVar(&flags.minScopeDepth, "min-scope-depth", 0,
+		"Only report shadows nested at least this many blocks below the outer variable")
+	Analyzer.Flags.BoolVar(&flags.explain, "explain", false,
+		"Print to stderr which allow-* rules fired for each shadow, without changing the diagnostics")
+	Analyzer.Flags.Var(&flags.messageTemplate, "message-template",
+		"Go text/template for diagnostic messages, with the fields {{.Inner}}, {{.Outer}}, {{.OuterPos}}, {{.Category}} and {{.Message}}")
+	Analyzer.Flags.IntVar(&flags.concurrency, "concurrency", 0,
+		"Number of files of a package to check at once; 0 means GOMAXPROCS")
+	Analyzer.Flags.BoolVar(&flags.strict, "strict", false,
+		"Turn off every allow-* rule and turn on every check-* flag, overriding both the individual flags and -config")
+	Analyzer.Flags.BoolVar(&flags.lenient, "lenient", false,
+		"Turn on every allow-* toggle, overriding both the individual flags and -config")
+	Analyzer.Flags.StringVar(&configPath, "config", "",
+		"Path to a JSON (or JSON-compatible YAML) file with per-package allow rules")
+	Analyzer.Flags.StringVar(&baselinePath, "baseline", "",
+		"Path to a file of known shadows, as written by redef -write-baseline, not to report again")
 }
@@ -1,9 +1,24 @@
 package redef
 
 import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/analysistest"
+	analysischecker "golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/packages"
 )
 
 func TestRedef(t *testing.T) {
@@ -18,11 +33,21 @@ func TestRedef(t *testing.T) {
 		"tablematch", "tablenomatch",
 		"guardnot", "laterfalse",
 		"guardonly", "latertrue",
+		"subtest", "typeassert",
+		"typeswitch", "capture", "categories",
+		"defernamed", "mixedassign", "stdlibio",
+		"directive", "selectshadow", "ordering", "siblings",
+		"nonvarmasked", "deferreturn",
+		"paramshadow", "rangekv", "shortouter", "vardecl",
+		"importshadow", "forinit", "okshadow",
+		"closureshadow", "defershadow", "typeshadow",
+		"elseifchain", "rebind",
+		"chanshadow",
 	)
 
 	// allow-dead-outer
 	Analyzer.Flags.Set("allow-dead-outer", "true")
-	analysistest.Run(t, testdata, Analyzer, "latertrue")
+	analysistest.Run(t, testdata, Analyzer, "latertrue", "laternested", "gotolive")
 	Analyzer.Flags.Set("allow-dead-outer", "false")
 
 	// allow-table-tests
@@ -30,9 +55,1044 @@ func TestRedef(t *testing.T) {
 	analysistest.Run(t, testdata, Analyzer, "tablematch")
 	Analyzer.Flags.Set("allow-table-tests", "false")
 
+	// allow-capture-shadow
+	Analyzer.Flags.Set("allow-capture-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "captureallow")
+	Analyzer.Flags.Set("allow-capture-shadow", "false")
+
+	// allow-guard-shadow with guards that log before exiting
+	Analyzer.Flags.Set("allow-guard-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "guardlog", "closureguard")
+	Analyzer.Flags.Set("allow-guard-shadow", "false")
+
+	// allow-ok-shadow
+	Analyzer.Flags.Set("allow-ok-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "okallow")
+	Analyzer.Flags.Set("allow-ok-shadow", "false")
+
+	// allow-import-shadow
+	Analyzer.Flags.Set("allow-import-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "importallow")
+	Analyzer.Flags.Set("allow-import-shadow", "false")
+
+	// allow-goroutine-shadow
+	Analyzer.Flags.Set("allow-goroutine-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "goshadowallow")
+	Analyzer.Flags.Set("allow-goroutine-shadow", "false")
+
+	// allow-const-shadow
+	Analyzer.Flags.Set("allow-const-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "constallow")
+	Analyzer.Flags.Set("allow-const-shadow", "false")
+
+	// allow-else-if-chain
+	Analyzer.Flags.Set("allow-else-if-chain", "true")
+	analysistest.Run(t, testdata, Analyzer, "elseifallow")
+	Analyzer.Flags.Set("allow-else-if-chain", "false")
+
+	// allow-rebind
+	Analyzer.Flags.Set("allow-rebind", "true")
+	analysistest.Run(t, testdata, Analyzer, "rebindallow")
+	Analyzer.Flags.Set("allow-rebind", "false")
+
+	// var and type declarations are only checked with -check-var-decls
+	// and -check-type-decls
+	Analyzer.Flags.Set("check-var-decls", "false")
+	analysistest.Run(t, testdata, Analyzer, "vardecloff")
+	Analyzer.Flags.Set("check-var-decls", "true")
+	Analyzer.Flags.Set("check-type-decls", "false")
+	analysistest.Run(t, testdata, Analyzer, "typedecloff")
+	Analyzer.Flags.Set("check-type-decls", "true")
+
+	// branch consistency is only checked with -check-branch-consistency,
+	// which then overrides the allow-* rules; without it, they apply
+	Analyzer.Flags.Set("check-branch-consistency", "true")
+	analysistest.Run(t, testdata, Analyzer, "branchmismatch")
+	Analyzer.Flags.Set("check-branch-consistency", "false")
+	analysistest.Run(t, testdata, Analyzer, "branchmismatchoff")
+	Analyzer.Flags.Set("allow-err-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "branchmismatchallow")
+	Analyzer.Flags.Set("allow-err-shadow", "false")
+
+	// allow-loop-shadow covers range keys and values alike, and loop
+	// bodies, but leaves for loop headers to allow-for-init
+	Analyzer.Flags.Set("allow-loop-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "rangekvallow", "loopbodyallow")
+	Analyzer.Flags.Set("allow-loop-shadow", "false")
+	Analyzer.Flags.Set("allow-for-init", "true")
+	analysistest.Run(t, testdata, Analyzer, "forinitallow")
+	Analyzer.Flags.Set("allow-for-init", "false")
+
 	// allow-guard-shadow; TODO: fix me
 	//Analyzer.Flags.Set("allow-guard-shadow", "true")
 	//analysistest.Run(t, testdata, Analyzer, "guardonly")
 	//Analyzer.Flags.Set("allow-guard-shadow", "false")
 
 }
+
+func TestErrTreadmill(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("warn-err-treadmill", "true")
+	analysistest.Run(t, testdata, Analyzer, "errtreadmill")
+	Analyzer.Flags.Set("warn-err-treadmill", "false")
+}
+
+func TestDroppedError(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("warn-dropped-error", "true")
+	analysistest.Run(t, testdata, Analyzer, "droppederr")
+	Analyzer.Flags.Set("warn-dropped-error", "false")
+}
+
+func TestSyncShadow(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	analysistest.Run(t, testdata, Analyzer, "syncshadow")
+
+	Analyzer.Flags.Set("check-sync-shadow", "false")
+	analysistest.Run(t, testdata, Analyzer, "syncshadowoff")
+	Analyzer.Flags.Set("check-sync-shadow", "true")
+}
+
+func TestPredeclared(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("check-predeclared", "true")
+	analysistest.Run(t, testdata, Analyzer, "predeclared")
+	Analyzer.Flags.Set("check-predeclared", "false")
+}
+
+func TestErrorChain(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	// allow-err-shadow does not cover errors.As and errors.Is.
+	Analyzer.Flags.Set("allow-err-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "errorchain")
+	Analyzer.Flags.Set("allow-err-shadow", "false")
+}
+
+func TestMetaFlags(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	// -strict overrides allowances that would suppress these.
+	for _, name := range []string{"allow-err-shadow", "allow-dead-outer", "allow-loop-shadow", "strict"} {
+		Analyzer.Flags.Set(name, "true")
+	}
+	Analyzer.Flags.Set("allow-names", "err,k,v")
+	analysistest.Run(t, testdata, Analyzer, "errshadow", "deadouter", "rangekv")
+	for _, name := range []string{"allow-err-shadow", "allow-dead-outer", "allow-loop-shadow", "strict"} {
+		Analyzer.Flags.Set(name, "false")
+	}
+	Analyzer.Flags.Set("allow-names", "")
+
+	// -lenient turns on allow-loop-shadow, among others.
+	Analyzer.Flags.Set("lenient", "true")
+	analysistest.Run(t, testdata, Analyzer, "rangekvallow")
+	Analyzer.Flags.Set("lenient", "false")
+}
+
+func TestConfig(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	// explicit CLI flags win over the config file
+	Analyzer.Flags.Set("allow-dead-outer", "false")
+
+	Analyzer.Flags.Set("config", filepath.Join(testdata, "config", "redef.json"))
+	analysistest.Run(t, testdata, Analyzer, "cfgallow", "cfgexplicit")
+	Analyzer.Flags.Set("config", "")
+
+	for _, name := range []string{"malformed.json", "unknown.json", "missing.json"} {
+		if _, err := readConfig(filepath.Join(testdata, "config", name)); err == nil {
+			t.Errorf("readConfig(%s): expected an error", name)
+		}
+	}
+}
+
+func TestPackageScope(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	// By default, only earlier declarations in the same file count.
+	analysistest.Run(t, testdata, Analyzer, "pkgscopedefault")
+
+	Analyzer.Flags.Set("include-package-scope", "true")
+	analysistest.Run(t, testdata, Analyzer, "pkgscope")
+	Analyzer.Flags.Set("include-package-scope", "false")
+}
+
+// Package-level variables declared in another file are not outers by
+// default, even when that file's positions come first.
+func TestPackageScopeOtherFile(t *testing.T) {
+	dir := filepath.Join(analysistest.TestData(), "src", "pkgscopedefault")
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range []string{"a.go", "b.go"} {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	info := &types.Info{
+		Defs:      make(map[*ast.Ident]types.Object),
+		Uses:      make(map[*ast.Ident]types.Object),
+		Implicits: make(map[ast.Node]types.Object),
+		Scopes:    make(map[ast.Node]*types.Scope),
+	}
+	if _, err := new(types.Config).Check("pkgscopedefault", fset, files, info); err != nil {
+		t.Fatal(err)
+	}
+
+	diags, err := Check(fset, files, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Name != "local" {
+		t.Errorf("got %+v, want only the shadow of local", diags)
+	}
+}
+
+func TestClusterByOuter(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("cluster-by-outer", "true")
+	results := analysistest.Run(t, testdata, Analyzer, "cluster")
+	Analyzer.Flags.Set("cluster-by-outer", "false")
+
+	for _, r := range results {
+		if len(r.Diagnostics) != 1 {
+			t.Fatalf("got %d diagnostics, want 1 clustered diagnostic", len(r.Diagnostics))
+		}
+		if got := len(r.Diagnostics[0].Related); got != 3 {
+			t.Errorf("got %d related locations, want 3", got)
+		}
+	}
+}
+
+// TestTypeSwitchCases checks that a type switch guard shadowing an
+// outer variable locates the cases using the shadow.
+func TestTypeSwitchCases(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	for _, r := range analysistest.Run(t, testdata, Analyzer, "typeswitch") {
+		var got []string
+		for _, d := range r.Diagnostics {
+			for _, rel := range d.Related {
+				got = append(got, fmt.Sprintf("%d: %s", r.Pass.Fset.Position(rel.Pos).Line, rel.Message))
+			}
+		}
+		want := []string{
+			`4: outer "v" declared here`,
+			`6: shadow "v" has type int in this case`,
+			`8: shadow "v" has type string in this case`,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got related\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
+
+func TestShadowChain(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	for _, r := range analysistest.Run(t, testdata, Analyzer, "shadowchain") {
+		var got []string
+		for _, d := range r.Diagnostics {
+			for _, rel := range d.Related[1:] {
+				got = append(got, fmt.Sprintf("%d: %s", r.Pass.Fset.Position(rel.Pos).Line, rel.Message))
+			}
+		}
+		want := []string{
+			`3: "x" also hidden here`,
+			`5: "x" also hidden here`,
+			`3: "x" also hidden here`,
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got related\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
+
+func TestAllowNames(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("include-package-scope", "true")
+	Analyzer.Flags.Set("allow-names", "ctx, logger")
+	analysistest.Run(t, testdata, Analyzer, "allownames")
+	Analyzer.Flags.Set("allow-names", "")
+	Analyzer.Flags.Set("include-package-scope", "false")
+}
+
+func TestFuncVarShadow(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("warn-func-var-shadow", "true")
+	Analyzer.Flags.Set("allow-dead-outer", "true")
+	analysistest.Run(t, testdata, Analyzer, "funcvar")
+	Analyzer.Flags.Set("allow-dead-outer", "false")
+	Analyzer.Flags.Set("warn-func-var-shadow", "false")
+}
+
+func TestMaxRedefs(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("max-redefs", "2")
+	analysistest.Run(t, testdata, Analyzer, "maxredefs")
+	Analyzer.Flags.Set("max-redefs", "0")
+}
+
+func TestLabelNameCollision(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("warn-label-name-collision", "true")
+	analysistest.Run(t, testdata, Analyzer, "labelname")
+	Analyzer.Flags.Set("warn-label-name-collision", "false")
+}
+
+func TestRenameFix(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.RunWithSuggestedFixes(t, testdata, Analyzer, "renamefix")
+
+	// Every fix of a file applied at once.
+	Analyzer.Flags.Set("warn-label-name-collision", "true")
+	analysistest.RunWithSuggestedFixes(t, testdata, Analyzer, "fixall")
+	Analyzer.Flags.Set("warn-label-name-collision", "false")
+}
+
+func TestMinScopeDepth(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("min-scope-depth", "2")
+	analysistest.Run(t, testdata, Analyzer, "scopedepth")
+	Analyzer.Flags.Set("min-scope-depth", "0")
+}
+
+func TestPoolShadow(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("warn-pool-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "poolshadow")
+	Analyzer.Flags.Set("warn-pool-shadow", "false")
+}
+
+func TestSSA(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("ssa", "true")
+	analysistest.Run(t, testdata, Analyzer, "lostwrite")
+	Analyzer.Flags.Set("ssa", "false")
+}
+
+func TestClosureParams(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("check-closure-params", "true")
+	analysistest.Run(t, testdata, Analyzer, "closureparam")
+	Analyzer.Flags.Set("check-closure-params", "false")
+}
+
+func TestContextShadow(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("warn-context-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "ctxshadow")
+	Analyzer.Flags.Set("warn-context-shadow", "false")
+}
+
+func TestNamedReturns(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("check-named-returns", "true")
+	Analyzer.Flags.Set("allow-err-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "namedreturn")
+	Analyzer.Flags.Set("allow-err-shadow", "false")
+	Analyzer.Flags.Set("check-named-returns", "false")
+}
+
+func TestRepeatedBlockDecl(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("warn-repeated-block-decl", "true")
+	analysistest.Run(t, testdata, Analyzer, "repeateddecl")
+	Analyzer.Flags.Set("warn-repeated-block-decl", "false")
+}
+
+func TestCheck(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(analysistest.TestData(), "src", "basic", "a.go"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := &types.Info{
+		Defs:      make(map[*ast.Ident]types.Object),
+		Uses:      make(map[*ast.Ident]types.Object),
+		Implicits: make(map[ast.Node]types.Object),
+		Scopes:    make(map[ast.Node]*types.Scope),
+	}
+	if _, err = new(types.Config).Check("basic", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	diags, err := Check(fset, []*ast.File{file}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics, want 1: %+v", len(diags), diags)
+	}
+
+	d := diags[0]
+	if d.Name != "x" || d.Category != kindShadow || d.Pos.Line != 8 || d.Outer.Line != 4 {
+		t.Errorf("got %+v, want x at line 8 shadowing line 4", d)
+	}
+	if !strings.Contains(d.Message, `shadows an outer "x" declared at a.go:4:2`) {
+		t.Errorf("unexpected message %q", d.Message)
+	}
+}
+
+// Check does not require Types, which the pool detection must do
+// without.
+func TestCheckPoolShadow(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filepath.Join(analysistest.TestData(), "src", "poolshadow", "a.go"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := &types.Info{
+		Defs:      make(map[*ast.Ident]types.Object),
+		Uses:      make(map[*ast.Ident]types.Object),
+		Implicits: make(map[ast.Node]types.Object),
+		Scopes:    make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err = conf.Check("poolshadow", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	Analyzer.Flags.Set("warn-pool-shadow", "true")
+	defer Analyzer.Flags.Set("warn-pool-shadow", "false")
+	diags, err := Check(fset, []*ast.File{file}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) == 0 || diags[0].Category != kindPool {
+		t.Errorf("got %+v, want a %s finding first", diags, kindPool)
+	}
+}
+
+func TestOrdering(t *testing.T) {
+	// Parse b.go first, so that token.Pos order disagrees with file name
+	// order.
+	dir := filepath.Join(analysistest.TestData(), "src", "ordering")
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range []string{"b.go", "a.go"} {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	info := &types.Info{
+		Defs:      make(map[*ast.Ident]types.Object),
+		Uses:      make(map[*ast.Ident]types.Object),
+		Implicits: make(map[ast.Node]types.Object),
+		Scopes:    make(map[ast.Node]*types.Scope),
+	}
+	pkg, err := new(types.Config).Check("ordering", fset, files, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emit := func() []string {
+		var got []string
+		pass := &analysis.Pass{
+			Analyzer:  Analyzer,
+			Fset:      fset,
+			Files:     files,
+			Pkg:       pkg,
+			TypesInfo: info,
+			ResultOf:  map[*analysis.Analyzer]any{inspect.Analyzer: inspector.New(files)},
+			Report: func(d analysis.Diagnostic) {
+				posn := fset.Position(d.Pos)
+				got = append(got, fmt.Sprintf("%s:%d:%d", filepath.Base(posn.Filename), posn.Line, posn.Column))
+			},
+		}
+		if _, err := Analyzer.Run(pass); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	for _, test := range []struct {
+		cluster string
+		want    string
+	}{
+		{"false", "a.go:6:3 b.go:6:3 b.go:11:3"},
+		{"true", "a.go:4:2 b.go:4:2"},
+	} {
+		Analyzer.Flags.Set("cluster-by-outer", test.cluster)
+		for range 3 {
+			if got := strings.Join(emit(), " "); got != test.want {
+				t.Errorf("cluster-by-outer=%s: got %s, want %s", test.cluster, got, test.want)
+			}
+		}
+	}
+	Analyzer.Flags.Set("cluster-by-outer", "false")
+
+	diags, err := Check(fset, files, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range diags {
+		got = append(got, fmt.Sprintf("%s:%d", filepath.Base(d.Pos.Filename), d.Pos.Line))
+	}
+	if want := "a.go:6 b.go:6 b.go:11"; strings.Join(got, " ") != want {
+		t.Errorf("Check: got %s, want %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestIgnoreGenerated(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("ignore-generated", "true")
+	analysistest.Run(t, testdata, Analyzer, "generated")
+	Analyzer.Flags.Set("ignore-generated", "false")
+
+	// Generated files are reported like any other without the flag.
+	analysistest.Run(t, testdata, Analyzer, "generatedoff")
+}
+
+func TestParamsStrict(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("allow-dead-outer", "true")
+	Analyzer.Flags.Set("params-strict", "true")
+	analysistest.Run(t, testdata, Analyzer, "paramstrict")
+	Analyzer.Flags.Set("params-strict", "false")
+	Analyzer.Flags.Set("allow-dead-outer", "false")
+}
+
+func TestSuggestReuse(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("suggest-reuse", "true")
+	analysistest.RunWithSuggestedFixes(t, testdata, Analyzer, "suggestreuse")
+	Analyzer.Flags.Set("suggest-reuse", "false")
+}
+
+func TestExcludeFuncs(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("exclude-funcs", `(*Server).Handle,(*List).Push,excludefuncs.Init,glob:excludefuncs.Init?*`)
+	analysistest.Run(t, testdata, Analyzer, "excludefuncs", "excludefuncsvalue")
+	Analyzer.Flags.Set("exclude-funcs", "")
+}
+
+// syntheticPackage type-checks a package of the given number of files,
+// each declaring funcs functions with a few shadows apiece.
+func syntheticPackage(tb testing.TB, files, funcs int) (*token.FileSet, []*ast.File, *types.Info) {
+	tb.Helper()
+
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for i := range files {
+		var src strings.Builder
+		src.WriteString("package synthetic\n\nimport \"errors\"\n\n")
+		for j := range funcs {
+			fmt.Fprintf(&src, `func f%d_%d(n int) (int, error) {
+	err := errors.New("x")
+	x := n
+	for i := range n {
+		x := i
+		_ = x
+	}
+	if n > 1 {
+		x, err := n+1, errors.New("y")
+		_, _ = x, err
+	}
+	{
+		x := 2
+		_ = x
+	}
+	return x, err
+}
+
+`, i, j)
+		}
+		file, err := parser.ParseFile(fset, fmt.Sprintf("f%d.go", i), src.String(), parser.ParseComments)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		parsed = append(parsed, file)
+	}
+
+	info := &types.Info{
+		Defs:      make(map[*ast.Ident]types.Object),
+		Uses:      make(map[*ast.Ident]types.Object),
+		Implicits: make(map[ast.Node]types.Object),
+		Scopes:    make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("synthetic", fset, parsed, info); err != nil {
+		tb.Fatal(err)
+	}
+	return fset, parsed, info
+}
+
+func TestConcurrency(t *testing.T) {
+	fset, files, info := syntheticPackage(t, 8, 10)
+
+	for _, flag := range []string{"warn-repeated-block-decl", "suggest-reuse", "allow-dead-outer"} {
+		Analyzer.Flags.Set(flag, "true")
+		defer Analyzer.Flags.Set(flag, "false")
+	}
+	Analyzer.Flags.Set("max-redefs", "1")
+	defer Analyzer.Flags.Set("max-redefs", "0")
+
+	var want []Diagnostic
+	for _, n := range []string{"1", "2", "8", "0"} {
+		Analyzer.Flags.Set("concurrency", n)
+		got, err := Check(fset, files, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			if len(got) == 0 {
+				t.Fatal("no diagnostics")
+			}
+			want = got
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("concurrency=%s: got %d diagnostics, differing from the %d found sequentially", n, len(got), len(want))
+		}
+	}
+	Analyzer.Flags.Set("concurrency", "0")
+}
+
+func BenchmarkConcurrency(b *testing.B) {
+	fset, files, info := syntheticPackage(b, 32, 200)
+
+	for _, bench := range []struct{ name, n string }{
+		{"sequential", "1"},
+		{"parallel", "0"},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			Analyzer.Flags.Set("concurrency", bench.n)
+			defer Analyzer.Flags.Set("concurrency", "0")
+			for b.Loop() {
+				if _, err := Check(fset, files, info); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRedundantLoopCopy(t *testing.T) {
+	// The fixture is a module of its own, declaring its Go version.
+	dir := filepath.Join(analysistest.TestData(), "loopcopy")
+
+	Analyzer.Flags.Set("check-redundant-loopcopy", "true")
+	analysistest.RunWithSuggestedFixes(t, dir, Analyzer, "./...")
+	Analyzer.Flags.Set("check-redundant-loopcopy", "false")
+}
+
+func TestNonVarOuters(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("check-nonvar-outers", "true")
+	analysistest.Run(t, testdata, Analyzer, "nonvar")
+	Analyzer.Flags.Set("check-nonvar-outers", "false")
+}
+
+func TestFuncOuters(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("check-func-outers", "true")
+	analysistest.Run(t, testdata, Analyzer, "funcshadow")
+	Analyzer.Flags.Set("check-func-outers", "false")
+}
+
+func TestExplain(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	var buf strings.Builder
+	explainOutput = &buf
+	defer func() { explainOutput = os.Stderr }()
+
+	// The diagnostics are those of a run without -explain.
+	Analyzer.Flags.Set("allow-dead-outer", "true")
+	Analyzer.Flags.Set("explain", "true")
+	analysistest.Run(t, testdata, Analyzer, "explain")
+	Analyzer.Flags.Set("explain", "false")
+	Analyzer.Flags.Set("allow-dead-outer", "false")
+
+	for _, want := range []string{
+		`a.go:7:3: redef: "x" shadowing a.go:4:2: suppressed by allow-dead-outer; rules enabled: allow-dead-outer`,
+		`a.go:13:3: redef: "y" shadowing a.go:11:2: reported; rules enabled: allow-dead-outer`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestMessageTemplate(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("message-template", "[LINT-7] {{.Category}}: {{.Inner}} shadows {{.Outer}} ({{.OuterPos}})")
+	analysistest.Run(t, testdata, Analyzer, "msgtemplate")
+
+	// Invalid templates leave the default messages in place.
+	for _, bad := range []string{"{{.Inner", "{{.Nope}}"} {
+		Analyzer.Flags.Set("message-template", bad)
+		if got := Analyzer.Flags.Lookup("message-template").Value.String(); got != "" {
+			t.Errorf("template %q was accepted as %q", bad, got)
+		}
+	}
+	analysistest.Run(t, testdata, Analyzer, "basic")
+	Analyzer.Flags.Set("message-template", "")
+}
+
+// TestCheckInvalidLHS feeds Check a declaration with non-name operands,
+// which only a tolerant type check lets through.
+func TestCheckInvalidLHS(t *testing.T) {
+	const src = `package p
+
+type T struct{ F int }
+
+func f() {
+	var b T
+	a := 0
+	{
+		a, b.F := 1, 2
+		(a) := 3
+		c, d[0] := 1, 2
+		_, _ = a, c
+	}
+	_, _ = a, b
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "a.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Defs:      make(map[*ast.Ident]types.Object),
+		Uses:      make(map[*ast.Ident]types.Object),
+		Implicits: make(map[ast.Node]types.Object),
+		Scopes:    make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{Error: func(error) {}}
+	conf.Check("p", fset, []*ast.File{file}, info)
+
+	diags, err := Check(fset, []*ast.File{file}, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Name != "a" || diags[0].Pos.Line != 9 {
+		t.Errorf("got %+v, want only a at line 9", diags)
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	for _, test := range []struct {
+		name, src string
+		note      bool
+	}{
+		{"valid", `package p
+
+func f() error {
+	var err error
+	if true {
+		err := g()
+		_ = err
+	}
+	return err
+}
+
+func g() error { return nil }
+`, false},
+		{"broken", `package p
+
+import "example.com/missing"
+
+func f() error {
+	err := missing.Do()
+	if true {
+		err := missing.Do()
+		_ = err
+	}
+	return err
+}
+`, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "a.go", test.src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var typeErrs int
+			conf := &types.Config{Error: func(error) { typeErrs++ }}
+			diags, err := CheckFile(fset, file, conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.note != (typeErrs > 0) {
+				t.Errorf("got %d type errors", typeErrs)
+			}
+			if len(diags) != 1 || diags[0].Name != "err" || diags[0].Outer.Line == 0 {
+				t.Fatalf("got %+v, want one shadow of err", diags)
+			}
+			if got := diags[0].Note != ""; got != test.note {
+				t.Errorf("got note %q, want one: %v", diags[0].Note, test.note)
+			}
+		})
+	}
+}
+
+func TestNameAffix(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("allow-name-prefix", "_")
+	Analyzer.Flags.Set("allow-name-suffix", "Shadow")
+	analysistest.Run(t, testdata, Analyzer, "nameaffix")
+	Analyzer.Flags.Set("allow-name-suffix", "")
+	Analyzer.Flags.Set("allow-name-prefix", "")
+}
+
+// TestResultShadows runs an analyzer that requires Analyzer and reports
+// the shadows listed in its Result.
+func TestResultShadows(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	downstream := &analysis.Analyzer{
+		Name:     "downstream",
+		Doc:      "reports the shadows found by redef",
+		Requires: []*analysis.Analyzer{Analyzer},
+		Run: func(pass *analysis.Pass) (any, error) {
+			for _, s := range pass.ResultOf[Analyzer].(*Result).Shadows {
+				outer := pass.Fset.Position(s.Outer)
+				pass.Reportf(s.Pos, "%s shadows %d:%d (%s)", s.Name, outer.Line, outer.Column, s.Category)
+			}
+			return nil, nil
+		},
+	}
+	analysistest.Run(t, testdata, downstream, "downstream")
+}
+
+func TestSameLine(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("allow-same-line", "true")
+	analysistest.Run(t, testdata, Analyzer, "sameline")
+	Analyzer.Flags.Set("allow-same-line", "false")
+}
+
+// TestVendor checks the vendored package directly; the shadow in it is
+// only reported with -include-vendor.
+func TestVendor(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	analysistest.Run(t, testdata, Analyzer, "vendored", "vendored/vendor/example.com/dep")
+}
+
+func TestSkipInitFuncs(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	analysistest.Run(t, testdata, Analyzer, "initfuncs")
+	Analyzer.Flags.Set("skip-init-funcs", "true")
+	analysistest.Run(t, testdata, Analyzer, "initskip")
+	Analyzer.Flags.Set("skip-init-funcs", "false")
+}
+
+func TestGoroutineShadow(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("warn-goroutine-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "goroutine")
+	Analyzer.Flags.Set("warn-goroutine-shadow", "false")
+}
+
+func TestCheckSelect(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("check-select", "false")
+	analysistest.Run(t, testdata, Analyzer, "selectoff")
+	Analyzer.Flags.Set("check-select", "true")
+}
+
+func TestTableTests(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("allow-table-tests", "true")
+	Analyzer.Flags.Set("table-test-funcs", "Run*Cases")
+	analysistest.Run(t, testdata, Analyzer, "tabletests")
+	Analyzer.Flags.Set("table-test-renames", "true")
+	analysistest.Run(t, testdata, Analyzer, "tablerenames")
+	Analyzer.Flags.Set("table-test-renames", "false")
+	Analyzer.Flags.Set("table-test-funcs", "")
+	Analyzer.Flags.Set("allow-table-tests", "false")
+}
+
+func TestTypeChange(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	// Same-type err shadows stay suppressed; differing types do not.
+	Analyzer.Flags.Set("warn-type-change", "true")
+	Analyzer.Flags.Set("allow-err-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "typechange")
+	Analyzer.Flags.Set("warn-type-change", "false")
+
+	// Type changes are classified as such by default, but only
+	// -warn-type-change puts them beyond the allow-* rules.
+	analysistest.Run(t, testdata, Analyzer, "typechangedefault")
+	Analyzer.Flags.Set("allow-err-shadow", "false")
+}
+
+func TestErrNamePattern(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("allow-err-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "errnames")
+	Analyzer.Flags.Set("err-name-pattern", "^fail")
+	analysistest.Run(t, testdata, Analyzer, "errpattern")
+	Analyzer.Flags.Set("err-name-pattern", defaultErrNamePattern)
+	Analyzer.Flags.Set("allow-err-shadow", "false")
+}
+
+func TestSummary(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("summary", "true")
+	analysistest.Run(t, testdata, Analyzer, "summary")
+
+	// Repeated declarations, found after the traversal, count toward
+	// the function declaring them.
+	Analyzer.Flags.Set("warn-repeated-block-decl", "true")
+	analysistest.Run(t, testdata, Analyzer, "summaryrepeated")
+	Analyzer.Flags.Set("warn-repeated-block-decl", "false")
+	Analyzer.Flags.Set("summary", "false")
+}
+
+func TestUnusedInner(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("warn-unused-inner", "true")
+	analysistest.Run(t, testdata, Analyzer, "unusedinner")
+	Analyzer.Flags.Set("warn-unused-inner", "false")
+}
+
+func TestReadAfterShadow(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("warn-read-after-shadow", "true")
+	analysistest.Run(t, testdata, Analyzer, "readafter")
+	Analyzer.Flags.Set("warn-read-after-shadow", "false")
+}
+
+func TestShortInit(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	for _, name := range []string{"allow-short-init", "allow-short-if"} {
+		Analyzer.Flags.Set(name, "true")
+		analysistest.Run(t, testdata, Analyzer, "shortinit")
+		Analyzer.Flags.Set(name, "false")
+	}
+}
+
+func TestOuterRelatedInformation(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	for _, r := range analysistest.Run(t, testdata, Analyzer, "basic") {
+		for _, d := range r.Diagnostics {
+			if len(d.Related) != 1 {
+				t.Fatalf("got %d related entries, want 1", len(d.Related))
+			}
+			if line := r.Pass.Fset.Position(d.Related[0].Pos).Line; line != 4 {
+				t.Errorf("related entry points at line %d, want 4", line)
+			}
+		}
+	}
+}
+
+func TestSkipCgo(t *testing.T) {
+	testdata := analysistest.TestData()
+
+	Analyzer.Flags.Set("skip-cgo", "true")
+	analysistest.Run(t, testdata, Analyzer, "cgonames")
+	Analyzer.Flags.Set("skip-cgo", "false")
+}
+
+// BenchmarkRun measures the analyzer over a generated package of
+// benchFiles files; package loading is excluded from the timing.
+func BenchmarkRun(b *testing.B) {
+	const benchFiles = 2000
+
+	files := map[string]string{"big/go.mod": "module big\n\ngo 1.22\n"}
+	for i := range benchFiles {
+		files[fmt.Sprintf("big/f%d.go", i)] = fmt.Sprintf(benchSource, i)
+	}
+	dir, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer cleanup()
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.LoadAllSyntax,
+		Dir:  filepath.Join(dir, "src", "big"),
+		Env:  append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod"),
+	}, ".")
+	if err != nil {
+		b.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		b.Fatal("errors loading benchmark package")
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		if _, err = analysischecker.Analyze([]*analysis.Analyzer{Analyzer}, pkgs, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+const benchSource = `package big
+
+func g%[1]d() (int, error) { return 0, nil }
+
+func f%[1]d(xs []int) (n int, err error) {
+	n, err = g%[1]d()
+	if err != nil {
+		return
+	}
+	for _, x := range xs {
+		if x > 0 {
+			n, err := g%[1]d()
+			_, _ = n, err
+		}
+		switch {
+		case x < 0:
+			err := error(nil)
+			_ = err
+		}
+	}
+	if v, err := g%[1]d(); err == nil {
+		_ = v
+	}
+	return
+}
+`
@@ -0,0 +1,169 @@
+package redef
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// With -ssa, the package is also built in SSA form, which tells whether
+// a write to a shadow is lost: whether, after the shadow is assigned,
+// control may reach a read of the outer variable without passing
+// through any assignment to it.
+
+// ssaSite locates the instruction of an identifier in the SSA form.
+type ssaSite struct {
+	block *ssa.BasicBlock
+	index int
+}
+
+// Verdicts of lostWrite.
+const (
+	ssaUnknown = iota
+	ssaLost
+	ssaHarmless
+)
+
+// ssaSites builds pass.Pkg in SSA form and maps every identifier of
+// its files holding a debug reference to the instruction. It returns
+// nil when the type information is too sparse to build from, as with
+// Check, or the build fails, as it may for ill-typed code.
+func ssaSites(pass *analysis.Pass) (sites map[*ast.Ident]ssaSite) {
+	info := pass.TypesInfo
+	if info.Types == nil || info.Selections == nil || info.Scopes == nil {
+		return nil
+	}
+	defer func() {
+		if recover() != nil {
+			sites = nil
+		}
+	}()
+
+	prog := ssa.NewProgram(pass.Fset, ssa.GlobalDebug|ssa.BuildSerially)
+	created := make(map[*types.Package]bool)
+	var create func(pkgs []*types.Package)
+	create = func(pkgs []*types.Package) {
+		for _, p := range pkgs {
+			if !created[p] {
+				created[p] = true
+				prog.CreatePackage(p, nil, nil, true)
+				create(p.Imports())
+			}
+		}
+	}
+	create(pass.Pkg.Imports())
+	pkg := prog.CreatePackage(pass.Pkg, pass.Files, info, false)
+	pkg.Build()
+
+	sites = make(map[*ast.Ident]ssaSite)
+	for fn := range ssautil.AllFunctions(prog) {
+		if fn.Pkg != pkg {
+			continue
+		}
+		for _, b := range fn.Blocks {
+			for i, instr := range b.Instrs {
+				if ref, ok := instr.(*ssa.DebugRef); ok {
+					if id, ok := ast.Unparen(ref.Expr).(*ast.Ident); ok {
+						sites[id] = ssaSite{b, i}
+					}
+				}
+			}
+		}
+	}
+	return sites
+}
+
+// lostWrite judges the shadow ident of outer within body, the function
+// body declaring outer. It returns ssaLost if a read of outer is
+// reachable from the shadow's definition without passing through an
+// assignment to outer, ssaHarmless if no read of outer is reachable
+// at all, and ssaUnknown if the SSA form cannot tell, e.g. because
+// outer is also used by a closure.
+func (c *checker) lostWrite(ident *ast.Ident, outer types.Object, body *ast.BlockStmt) int {
+	def, ok := c.ssaSites[ident]
+	if !ok || body == nil {
+		return ssaUnknown
+	}
+
+	// events of outer per block, by instruction index
+	type event struct {
+		index int
+		read  bool
+	}
+	events := make(map[*ssa.BasicBlock][]event)
+	unknown := false
+	add := func(id *ast.Ident, read bool) {
+		site, ok := c.ssaSites[id]
+		if !ok || site.block.Parent() != def.block.Parent() {
+			unknown = true
+			return
+		}
+		events[site.block] = append(events[site.block], event{site.index, read})
+	}
+	written := make(map[*ast.Ident]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if id, ok := ast.Unparen(lhs).(*ast.Ident); ok && c.pass.TypesInfo.Uses[id] == outer {
+					written[id] = true
+					add(id, n.Tok != token.ASSIGN && n.Tok != token.DEFINE)
+				}
+			}
+		case *ast.IncDecStmt:
+			if id, ok := ast.Unparen(n.X).(*ast.Ident); ok && c.pass.TypesInfo.Uses[id] == outer {
+				written[id] = true
+				add(id, true)
+			}
+		case *ast.Ident:
+			if c.pass.TypesInfo.Uses[n] == outer && !written[n] {
+				add(n, true)
+			}
+		}
+		return !unknown
+	})
+	if unknown {
+		return ssaUnknown
+	}
+
+	// reach reports whether a read is reachable from def, stopping
+	// at writes unless throughWrites is set.
+	reach := func(throughWrites bool) bool {
+		seen := make(map[*ssa.BasicBlock]bool)
+		var visit func(b *ssa.BasicBlock, from int) bool
+		visit = func(b *ssa.BasicBlock, from int) bool {
+			first := -1
+			for i, e := range events[b] {
+				if e.index >= from && (first < 0 || e.index < events[b][first].index) {
+					if e.read || !throughWrites {
+						first = i
+					}
+				}
+			}
+			if first >= 0 {
+				return events[b][first].read
+			}
+			for _, succ := range b.Succs {
+				if !seen[succ] {
+					seen[succ] = true
+					if visit(succ, 0) {
+						return true
+					}
+				}
+			}
+			return false
+		}
+		return visit(def.block, def.index+1)
+	}
+	switch {
+	case reach(false):
+		return ssaLost
+	case !reach(true):
+		return ssaHarmless
+	}
+	return ssaUnknown
+}
@@ -0,0 +1,137 @@
+package redef
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// FuncSummary aggregates the findings within one function, as reported
+// in -summary mode.
+type FuncSummary struct {
+	// Func names the function, e.g. "func (*T) Foo", or locates a
+	// function literal.
+	Func string `json:"func"`
+
+	// Posn is the position of the function, as file:line:column.
+	Posn string `json:"posn"`
+
+	// Shadows is the number of findings within the function.
+	Shadows int `json:"shadows"`
+
+	// Kinds counts the findings by short kind, such as "err" for
+	// redef.shadow.err, "plain" for redef.shadow or "type-assert"
+	// for redef.type-assert.
+	Kinds map[string]int `json:"kinds"`
+}
+
+// flushSummary reports one diagnostic per function in place of the
+// individual findings, and keeps the summaries for the Result.
+func (c *checker) flushSummary(findings []finding) {
+	funcs := make(map[*ast.BlockStmt]ast.Node)
+	for _, f := range c.pass.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch fn := n.(type) {
+			case *ast.FuncDecl:
+				if fn.Body != nil {
+					funcs[fn.Body] = fn
+				}
+			case *ast.FuncLit:
+				funcs[fn.Body] = fn
+			}
+			return true
+		})
+	}
+
+	var order []ast.Node
+	byFunc := make(map[ast.Node]*FuncSummary)
+	for _, f := range findings {
+		fn := funcs[f.fn]
+		if fn == nil {
+			continue
+		}
+		s, ok := byFunc[fn]
+		if !ok {
+			s = &FuncSummary{
+				Func:  c.funcName(fn),
+				Posn:  c.shortPos(fn.Pos()),
+				Kinds: make(map[string]int),
+			}
+			byFunc[fn] = s
+			order = append(order, fn)
+		}
+		s.Shadows++
+		s.Kinds[shortKind(f.kind)]++
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return c.before(order[i].Pos(), order[j].Pos())
+	})
+
+	for _, fn := range order {
+		s := byFunc[fn]
+		c.summaries = append(c.summaries, *s)
+		c.pass.Report(analysis.Diagnostic{
+			Pos:      fn.Pos(),
+			Category: kindSummary,
+			Message:  s.String(),
+		})
+	}
+}
+
+// String formats s as, e.g., "func (T) Foo: 3 shadows (2 err, 1 loop)".
+func (s FuncSummary) String() string {
+	kinds := make([]string, 0, len(s.Kinds))
+	for kind := range s.Kinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		a, b := kinds[i], kinds[j]
+		if s.Kinds[a] != s.Kinds[b] {
+			return s.Kinds[a] > s.Kinds[b]
+		}
+		return a < b
+	})
+
+	counts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		counts[i] = fmt.Sprintf("%d %s", s.Kinds[kind], kind)
+	}
+
+	noun := "shadows"
+	if s.Shadows == 1 {
+		noun = "shadow"
+	}
+	return fmt.Sprintf("%s: %d %s (%s)", s.Func, s.Shadows, noun, strings.Join(counts, ", "))
+}
+
+// funcName names fn as in its declaration, without parameters, or
+// locates it if it is a function literal.
+func (c *checker) funcName(fn ast.Node) string {
+	switch fn := fn.(type) {
+	case *ast.FuncDecl:
+		if fn.Recv == nil || len(fn.Recv.List) == 0 {
+			return "func " + fn.Name.Name
+		}
+		recv := types.ExprString(fn.Recv.List[0].Type)
+		return fmt.Sprintf("func (%s) %s", recv, fn.Name.Name)
+	default:
+		return "func literal at " + c.shortPos(fn.Pos())
+	}
+}
+
+// shortKind abbreviates a kind for summaries: "redef.shadow.err"
+// becomes "err", "redef.shadow" becomes "plain", and other kinds lose
+// their "redef." prefix.
+func shortKind(kind string) string {
+	if kind == kindShadow {
+		return "plain"
+	}
+	if rest, ok := strings.CutPrefix(kind, kindShadow+"."); ok {
+		return rest
+	}
+	return strings.TrimPrefix(kind, "redef.")
+}
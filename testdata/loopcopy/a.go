@@ -0,0 +1,33 @@
+package loopcopy
+
+func use(func()) {}
+
+func ranges(xs []int) {
+	for i, x := range xs {
+		i := i // want `variable "i" is redefined as a copy of the loop variable`
+		x := x // want `variable "x" is redefined as a copy of the loop variable`
+		use(func() { _, _ = i, x })
+	}
+}
+
+func threeClause() {
+	for i := 0; i < 3; i++ {
+		i := i // want `variable "i" is redefined as a copy of the loop variable`
+		use(func() { _ = i })
+	}
+}
+
+// Copies of anything else are ordinary shadows.
+func others(xs []int) {
+	n := len(xs)
+	for range xs {
+		n := n // want `variable "n" is redefined and shadows an outer "n"`
+		use(func() { _ = n })
+	}
+	for i := range xs {
+		if i > 0 {
+			i := i // want `variable "i" is redefined and shadows an outer "i"`
+			use(func() { _ = i })
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module loopcopy
+
+go 1.22
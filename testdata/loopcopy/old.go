@@ -0,0 +1,11 @@
+//go:build go1.21
+
+package loopcopy
+
+// This file keeps the old loop semantics, so the copy is needed.
+func old(xs []int) {
+	for _, x := range xs {
+		x := x // want `variable "x" is redefined and shadows an outer "x"`
+		use(func() { _ = x })
+	}
+}
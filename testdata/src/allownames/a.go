@@ -0,0 +1,17 @@
+package allownames
+
+import "context"
+
+func get() (context.Context, error) { return context.Background(), nil }
+
+func f(ctx context.Context) {
+	logger := "root"
+	if ctx != nil {
+		ctx, err := get() // want `"err" is redefined`
+		logger := "child"
+		_, _, _ = ctx, err, logger
+	}
+	_ = logger
+}
+
+var err error
@@ -5,7 +5,7 @@ func f() {
 	_ = x
 
 	if true {
-		x := 2 // want "redefined"
+		x := 2 // want `shadows an outer "x" declared at a.go:4:2`
 		_ = x
 	}
 }
@@ -0,0 +1,51 @@
+package branchmismatch
+
+func f() error { return nil }
+func g() error { return nil }
+
+func ifElse(ok bool) error {
+	var err error
+	if ok {
+		err = f()
+	} else {
+		err := g() // want `variable "err" is redefined with ':=' in this branch, while a sibling branch assigns to the outer "err" with '=' at a.go:9:3; the value set here does not reach the outer variable$`
+		_ = err
+	}
+	return err
+}
+
+func elseIf(n int) error {
+	var err error
+	if n == 0 {
+		err := f() // want `sibling branch assigns to the outer "err" with '=' at a.go:23:3`
+		_ = err
+	} else if n == 1 {
+		err = g()
+	}
+	return err
+}
+
+func cases(n int) error {
+	var err error
+	switch n {
+	case 0:
+		err = f()
+	default:
+		err := g() // want `sibling branch assigns to the outer "err" with '=' at a.go:32:3`
+		_ = err
+	}
+	return err
+}
+
+// Consistent branches are ordinary shadows.
+func consistent(ok bool) error {
+	err := f()
+	if ok {
+		err := g() // want `variable "err" is redefined and shadows an outer "err"`
+		_ = err
+	} else {
+		err := f() // want `variable "err" is redefined and shadows an outer "err"`
+		_ = err
+	}
+	return err
+}
@@ -0,0 +1,15 @@
+package branchmismatchallow
+
+func f() error { return nil }
+
+// Without -check-branch-consistency, -allow-err-shadow hides the shadow.
+func ifElse(ok bool) error {
+	var err error
+	if ok {
+		err = f()
+	} else {
+		err := f()
+		_ = err
+	}
+	return err
+}
@@ -0,0 +1,14 @@
+package branchmismatchoff
+
+func f() error { return nil }
+
+func ifElse(ok bool) error {
+	var err error
+	if ok {
+		err = f()
+	} else {
+		err := f() // want `variable "err" is redefined and shadows an outer "err"`
+		_ = err
+	}
+	return err
+}
@@ -0,0 +1,12 @@
+package buildtags
+
+func f() int { return 0 }
+
+func common() int {
+	x := f()
+	{
+		x := f() // want `variable "x" is redefined`
+		_ = x
+	}
+	return x
+}
@@ -0,0 +1,10 @@
+package buildtags
+
+func windows() int {
+	y := f()
+	{
+		y := f() // want `variable "y" is redefined`
+		_ = y
+	}
+	return y
+}
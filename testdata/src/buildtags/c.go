@@ -0,0 +1,12 @@
+//go:build foo
+
+package buildtags
+
+func tagged() int {
+	z := f()
+	{
+		z := f() // want `variable "z" is redefined`
+		_ = z
+	}
+	return z
+}
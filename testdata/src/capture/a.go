@@ -0,0 +1,14 @@
+package capture
+
+func g() error { return nil }
+
+func f(items []int) error {
+	err := g()
+	for range items {
+		err := g() // want "redefined"
+		go func() {
+			_ = err
+		}()
+	}
+	return err
+}
@@ -0,0 +1,33 @@
+package captureallow
+
+func g() error     { return nil }
+func report(error) {}
+
+func goroutine(items []int) error {
+	err := g()
+	for range items {
+		err := g()
+		go func() {
+			report(err)
+		}()
+	}
+	return err
+}
+
+func deferred() error {
+	err := g()
+	if err == nil {
+		err := g()
+		defer func() { report(err) }()
+	}
+	return err
+}
+
+func plainClosure() error {
+	err := g()
+	if err == nil {
+		err := g() // want "redefined"
+		func() { report(err) }()
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package categories
+
+func f() error { return nil }
+
+func errShadow() error {
+	err := f()
+	if err == nil {
+		err := f() // want `variable "err" is redefined`
+		_ = err
+	}
+	return err
+}
+
+func loopShadow(xs []int) int {
+	n := 0
+	for _, x := range xs {
+		n := x // want `variable "n" is redefined`
+		_ = n
+	}
+	return n
+}
+
+func guardShadow() int {
+	v := 1
+	if v < 0 {
+		return 0
+	}
+	{
+		v := 2 // want `variable "v" is redefined`
+		_ = v
+	}
+	return v
+}
+
+func plainShadow() int {
+	p := 1
+	{
+		p := 2 // want `variable "p" is redefined`
+		_ = p
+	}
+	return p
+}
+
+func paramShadow(q int) int {
+	{
+		q := 2 // want `variable "q" is redefined`
+		_ = q
+	}
+	return q
+}
+
+type T struct{}
+
+func (r T) recvShadow() T {
+	{
+		r := T{} // want `variable "r" is redefined`
+		_ = r
+	}
+	return r
+}
+
+func forInitShadow(xs []int) int {
+	j := 0
+	for j := 0; j < len(xs); j++ { // want `variable "j" is redefined`
+	}
+	return j
+}
+
+func okShadow(m map[string]int) bool {
+	_, ok := m["a"]
+	if ok {
+		_, ok := m["b"] // want `variable "ok" is redefined`
+		return ok
+	}
+	return ok
+}
+
+func closureShadow() func() {
+	c := 1
+	_ = c
+	return func() {
+		c := 2 // want `variable "c" is redefined`
+		_ = c
+	}
+}
@@ -0,0 +1,11 @@
+package cfgallow
+
+func g() error { return nil }
+
+func f() {
+	err := g()
+	if err := g(); err != nil {
+		return
+	}
+	_ = err
+}
@@ -0,0 +1,16 @@
+package cgonames
+
+func f() int {
+	_cgo_tmp := 1
+	if _cgo_tmp > 0 {
+		_cgo_tmp := 2
+		_ = _cgo_tmp
+	}
+
+	x := _cgo_tmp
+	if x > 0 {
+		x := 2 // want "redefined"
+		_ = x
+	}
+	return x
+}
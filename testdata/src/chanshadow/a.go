@@ -0,0 +1,35 @@
+package chanshadow
+
+func worker(done chan<- struct{}) { close(done) }
+
+func wait() {
+	done := make(chan struct{})
+	{
+		done := make(chan struct{}) // want `variable "done" is redefined and shadows the channel "done" declared at a.go:6:2; sends, receives and closes in the rest of the block use a different channel, which may deadlock`
+		go worker(done)
+	}
+	<-done
+}
+
+type results chan int
+
+func loop(n int) int {
+	out := make(results, n)
+	for i := range n {
+		out := make(results, 1) // want `shadows the channel "out" declared at a.go:17:2`
+		out <- i
+		<-out
+	}
+	close(out)
+	return len(out)
+}
+
+// The ok of a receive keeps its own classification.
+func recv(ch chan int) bool {
+	_, ok := <-ch
+	if ok {
+		v, ok := <-ch // want `variable "ok" is redefined and shadows an outer "ok"`
+		return v > 0 && ok
+	}
+	return ok
+}
@@ -0,0 +1,37 @@
+package closureguard
+
+func g() (*int, error) { return nil, nil }
+
+func use(*int) {}
+
+// x is used outside a guard clause by the closure declaring it, before
+// the inner closure shadows it.
+func unguarded() {
+	f := func() {
+		x, _ := g()
+		use(x)
+		h := func() {
+			x := new(int) // want `variable "x" is redefined`
+			use(x)
+		}
+		h()
+	}
+	f()
+}
+
+// Only guard clauses use x before the inner closure.
+func guarded() {
+	f := func() error {
+		x, err := g()
+		if x == nil {
+			return err
+		}
+		h := func() {
+			x := new(int)
+			use(x)
+		}
+		h()
+		return nil
+	}
+	_ = f()
+}
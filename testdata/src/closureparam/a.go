@@ -0,0 +1,22 @@
+package closureparam
+
+import "testing"
+
+func handle(func(error)) {}
+
+func f() error {
+	var err error
+	handle(func(err error) { // want `parameter "err" of the function literal shadows the variable "err" declared at a.go:8:6, which is out of reach inside the literal$`
+		_ = err
+	})
+	n := 0
+	_ = func(n, m int) int { return n + m } // want `parameter "n" of the function literal shadows the variable "n" declared at a.go:12:2`
+	_ = func(_ int, err2 error) {}
+	_ = n
+	return err
+}
+
+// Subtests take their own t by design.
+func TestSub(t *testing.T) {
+	t.Run("sub", func(t *testing.T) {})
+}
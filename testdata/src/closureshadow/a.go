@@ -0,0 +1,22 @@
+package closureshadow
+
+var global = 0
+
+func f() func() int {
+	a, b := 1, 2
+	_ = a
+	return func() int {
+		a := 3 // want `variable "a" is redefined inside a closure and shadows "a" declared at a.go:6:2 in the enclosing function$`
+		{
+			b := a // want `variable "b" is redefined inside a closure and shadows "b" declared at a.go:6:5 in the enclosing function, which the closure also captures$`
+			_ = b
+		}
+		c := b
+		{
+			c := 4 // want `variable "c" is redefined and shadows an outer "c" declared at a.go:14:3$`
+			_ = c
+		}
+		global := 5 // want `variable "global" is redefined and shadows an outer "global"`
+		return a + c + global
+	}
+}
@@ -0,0 +1,18 @@
+package cluster
+
+func g() error { return nil }
+
+func f() error {
+	err := g() // want `variable "err" is redefined 3 times`
+	if err := g(); err != nil {
+		return err
+	}
+	if err := g(); err != nil {
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		err := g()
+		_ = err
+	}
+	return err
+}
@@ -0,0 +1,11 @@
+package constallow
+
+const maxRetries = 3
+
+var attempts = 0
+
+func f(n int) int {
+	maxRetries := n
+	attempts := maxRetries // want `variable "attempts" is redefined and shadows an outer "attempts"`
+	return attempts
+}
@@ -0,0 +1,45 @@
+package ctxshadow
+
+import (
+	"context"
+	"time"
+)
+
+func call(context.Context) error { return nil }
+
+func lost(ctx context.Context, slow bool) error {
+	if slow {
+		ctx, cancel := context.WithTimeout(ctx, time.Second) // want `variable "ctx" is redefined by context.WithTimeout and shadows "ctx" declared at a.go:10:11; neither the derived context nor its cancel func leaves this block, so code after it still uses the outer context$`
+		defer cancel()
+		_ = ctx
+	}
+	return call(ctx)
+}
+
+// Returning the derived context hands it on.
+func returned(ctx context.Context) (context.Context, context.CancelFunc) {
+	{
+		ctx, cancel := context.WithCancel(ctx) // want `variable "ctx" is redefined and shadows the parameter "ctx"`
+		return ctx, cancel
+	}
+}
+
+// So does storing the cancel func outside the block.
+func stored(ctx context.Context) error {
+	var stop context.CancelFunc
+	{
+		ctx, cancel := context.WithCancel(ctx) // want `variable "ctx" is redefined and shadows the parameter "ctx"`
+		stop = cancel
+		_ = ctx
+	}
+	defer stop()
+	return call(ctx)
+}
+
+// Other calls are left alone.
+func other(ctx context.Context) {
+	{
+		ctx := context.Background() // want `variable "ctx" is redefined and shadows the parameter "ctx"`
+		_ = ctx
+	}
+}
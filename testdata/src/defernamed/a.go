@@ -0,0 +1,40 @@
+package defernamed
+
+type span struct{}
+
+func (span) End(*error) {}
+
+func start() span        { return span{} }
+func record(*int)        {}
+func work() (int, error) { return 0, nil }
+
+func traced() (err error) {
+	s := start()
+	defer s.End(&err)
+
+	if true {
+		_, err := work() // want `the shadow causes the deferred call at a.go:13:2 to observe a stale named return`
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func counted() (result int) {
+	defer func() { record(&result) }()
+
+	for i := 0; i < 3; i++ {
+		result, err := work() // want `observe a stale named return`
+		_, _ = result, err
+	}
+	return
+}
+
+func untraced() (n int) {
+	if true {
+		n := 1 // want `variable "n" is redefined and shadows an outer "n"`
+		_ = n
+	}
+	return
+}
@@ -0,0 +1,44 @@
+package deferreturn
+
+import "fmt"
+
+func work() error { return nil }
+
+func recovered() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("panic: %v", r) // want `variable "err" is redefined inside a deferred closure and shadows the named result "err", so assigning to it will not affect what the function returns`
+			_ = err
+		}
+	}()
+	return work()
+}
+
+func background() (n int, err error) {
+	go func() {
+		n := 1 // want `variable "n" is redefined inside a go closure and shadows the named result "n"`
+		_ = n
+	}()
+	return 0, nil
+}
+
+// A closure that is merely called is an ordinary shadow.
+func called() (err error) {
+	func() {
+		err := work() // want `variable "err" is redefined inside a closure and shadows "err"`
+		_ = err
+	}()
+	return
+}
+
+// The deferred closure's own results are its business.
+func own() (err error) {
+	defer func() (err error) {
+		{
+			err := work() // want `variable "err" is redefined and shadows an outer "err"`
+			_ = err
+		}
+		return nil
+	}()
+	return nil
+}
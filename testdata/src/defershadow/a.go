@@ -0,0 +1,28 @@
+package defershadow
+
+func cleanup() error { return nil }
+
+func f() error {
+	var err error
+	defer func() {
+		err := cleanup() // want `variable "err" is redefined inside a deferred closure and hides the outer "err" declared at a.go:6:6; the outer value will not be updated$`
+		_ = err
+	}()
+	go func() {
+		err := cleanup() // want `variable "err" is redefined inside a goroutine and hides the outer "err"`
+		_ = err
+	}()
+	return err
+}
+
+// Variables of the deferred closure itself are ordinary outers.
+func g() {
+	defer func() {
+		n := 1
+		{
+			n := 2 // want `variable "n" is redefined and shadows an outer "n" declared at a.go:21:3$`
+			_ = n
+		}
+		_ = n
+	}()
+}
@@ -0,0 +1,61 @@
+package directive
+
+func f() int { return 0 }
+
+func sameLine() int {
+	x := f()
+	{
+		x := f() //redef:ignore x is only a scratch copy
+		_ = x
+	}
+	return x
+}
+
+func lineAbove() int {
+	x := f()
+	{
+		//redef:ignore
+		x := f()
+		_ = x
+	}
+	return x
+}
+
+func nolint() int {
+	x, y := f(), f()
+	{
+		x := f() //nolint:redef
+		_ = x
+	}
+	{
+		//nolint:errcheck,redef // intentional
+		y := f()
+		_ = y
+	}
+	return x + y
+}
+
+func unrelated() int {
+	x, y := f(), f()
+	{
+		x := f() //nolint:errcheck // want `variable "x" is redefined`
+		_ = x
+	}
+	{
+		// redef:ignore is not a directive with the space.
+		y := f() // want `variable "y" is redefined`
+		_ = y
+	}
+	return x + y
+}
+
+func tooFar() int {
+	x := f()
+	{
+		//redef:ignore
+
+		x := f() // want `variable "x" is redefined`
+		_ = x
+	}
+	return x
+}
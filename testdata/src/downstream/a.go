@@ -0,0 +1,15 @@
+package downstream
+
+func f() error { return nil }
+
+func g() error {
+	x := 1
+	err := f()
+	{
+		x := 2     // want `x shadows 6:2 \(redef.shadow\)`
+		err := f() // want `err shadows 7:2 \(redef.shadow.err\)`
+		_, _ = x, err
+	}
+	_ = x
+	return err
+}
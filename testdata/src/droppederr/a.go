@@ -0,0 +1,55 @@
+package droppederr
+
+import "log"
+
+func f() error { return nil }
+
+func dropped() error {
+	err := f()
+	if err == nil {
+		err := f() // want `variable "err" is redefined and shadows an outer "err" declared at a.go:8:2, but is never checked or returned, while the outer "err" is returned at a.go:13:2; the error is likely lost`
+		_ = err
+	}
+	return err
+}
+
+// Checked shadows are plain ones.
+func checked() error {
+	err := f()
+	if err == nil {
+		err := f() // want `variable "err" is redefined and shadows an outer "err" declared at a.go:18:2$`
+		if err != nil {
+			log.Print(err)
+		}
+	}
+	return err
+}
+
+// So are shadows bound to nil, which hold nothing to lose.
+func nilShadow() error {
+	err := f()
+	{
+		var x int
+		x, err := 1, error(nil) // want `variable "err" is redefined and shadows an outer "err" declared at a.go:30:2$`
+		_, _ = x, err
+	}
+	{
+		err := error(nil) // want `variable "err" is redefined`
+		_ = err
+	}
+	{
+		var err error // want `variable "err" is redefined`
+		_ = err
+	}
+	return err
+}
+
+// Nothing is lost if the outer error is never returned.
+func notReturned() {
+	err := f()
+	{
+		err := f() // want `variable "err" is redefined and shadows an outer "err" declared at a.go:49:2$`
+		_ = err
+	}
+	log.Print(err)
+}
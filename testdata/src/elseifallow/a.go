@@ -0,0 +1,12 @@
+package elseifallow
+
+func a() error { return nil }
+
+func chain() error {
+	if err := a(); err != nil {
+		return err
+	} else if err := a(); err != nil {
+		return err
+	}
+	return nil
+}
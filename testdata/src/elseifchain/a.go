@@ -0,0 +1,27 @@
+package elseifchain
+
+func a() error { return nil }
+func b() error { return nil }
+
+func chain() error {
+	if err := a(); err != nil {
+		return err
+	} else if err := b(); err != nil { // want `variable "err" is redeclared in the init of 3 clauses of an else-if chain, each shadowing the one before, at a.go:9:12, a.go:11:12$`
+		return err
+	} else if err := a(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// The chain ends at the first clause not redeclaring the name.
+func broken(ok bool) error {
+	if err := a(); err != nil {
+		return err
+	} else if ok {
+		return nil
+	} else if err := b(); err != nil { // want `variable "err" is redefined and shadows an outer "err" declared at a.go:19:5$`
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package errnames
+
+import "io"
+
+func read(r io.Reader, buf []byte) (int, error) { return r.Read(buf) }
+
+func names(r io.ReadCloser, buf []byte) error {
+	_, rerr := read(r, buf)
+	if rerr == nil {
+		_, rerr := read(r, buf)
+		_ = rerr
+	}
+	closeErr := r.Close()
+	{
+		closeErr := r.Close()
+		_ = closeErr
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return rerr
+}
+
+// Any two error variables qualify, whatever their names.
+func typed(r io.ReadCloser) error {
+	e := r.Close()
+	{
+		e := r.Close()
+		_ = e
+	}
+	return e
+}
+
+// Neither name nor type makes n an error variable.
+func other(r io.Reader, buf []byte) int {
+	n, _ := read(r, buf)
+	{
+		n, _ := read(r, buf) // want `variable "n" is redefined`
+		_ = n
+	}
+	return n
+}
+
+// Names merely containing "err" do not make counters error variables.
+func counts(xs []error) (int, int) {
+	errCount, stderrCount := 0, 0
+	for _, x := range xs {
+		errCount := errCount + 1 // want `variable "errCount" is redefined`
+		stderrCount := 1         // want `variable "stderrCount" is redefined`
+		_, _, _ = x, errCount, stderrCount
+	}
+	return errCount, stderrCount
+}
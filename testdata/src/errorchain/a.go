@@ -0,0 +1,31 @@
+package errorchain
+
+import (
+	"errors"
+	"io"
+)
+
+type MyErr struct{}
+
+func (*MyErr) Error() string { return "" }
+
+func do() error { return nil }
+
+func f() error {
+	err := do()
+	var target *MyErr
+	if err := do(); errors.As(err, &target) { // want `variable "err" is redefined and shadows "err" declared at a.go:15:2, then inspected with errors.As`
+		return err
+	}
+	{
+		err := do() // want `then inspected with errors.Is`
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+	// Other uses are left to -allow-err-shadow.
+	if err := do(); err != nil {
+		return err
+	}
+	return err
+}
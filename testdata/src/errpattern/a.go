@@ -0,0 +1,12 @@
+package errpattern
+
+func check() bool { return false }
+
+func custom() bool {
+	failed := check()
+	if !failed {
+		failed := check()
+		_ = failed
+	}
+	return failed
+}
@@ -0,0 +1,34 @@
+package errtreadmill
+
+func step() error { return nil }
+
+func f() error {
+	err := step()
+	if err := step(); err != nil { // want `shadows an outer "err" declared at a.go:6:2`
+		return err
+	}
+	if err := step(); err != nil { // want `shadows an outer "err" declared at a.go:6:2`
+		return err
+	}
+	if err := step(); err != nil { // want `redeclared in the init of 3 sibling if statements, each shadowing the outer "err" declared at a.go:6:2`
+		return err
+	}
+	return err
+}
+
+// Separate blocks do not add up.
+func g() error {
+	err := step()
+	if err := step(); err != nil { // want `shadows an outer "err"`
+		return err
+	}
+	{
+		if err := step(); err != nil { // want `shadows an outer "err"`
+			return err
+		}
+		if err := step(); err != nil { // want `shadows an outer "err"`
+			return err
+		}
+	}
+	return err
+}
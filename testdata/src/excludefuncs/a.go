@@ -0,0 +1,77 @@
+package excludefuncs
+
+type Server struct{}
+
+type List[T any] struct{}
+
+func (s *Server) Handle() {
+	x := 1
+	{
+		x := 2
+		_ = x
+	}
+	_ = x
+}
+
+// "(*Server).Handle" names no other type's Handle.
+type OtherServer struct{}
+
+func (s *OtherServer) Handle() {
+	x := 1
+	{
+		x := 2 // want `variable "x" is redefined`
+		_ = x
+	}
+	_ = x
+}
+
+// Other methods of Server are still checked.
+func (s Server) Serve() {
+	x := 1
+	{
+		x := 2 // want `variable "x" is redefined`
+		_ = x
+	}
+	_ = x
+}
+
+func (l *List[T]) Push() {
+	x := 1
+	{
+		x := 2
+		_ = x
+	}
+	_ = x
+}
+
+func Init() {
+	x := 1
+	{
+		x := 2
+		_ = x
+	}
+	_ = x
+	func() {
+		x := 3
+		_ = x
+	}()
+}
+
+// Matched by the glob: pattern.
+func InitAll() {
+	x := 1
+	{
+		x := 2
+		_ = x
+	}
+	_ = x
+}
+
+func sibling() {
+	x := 1
+	{
+		x := 2 // want `variable "x" is redefined`
+		_ = x
+	}
+	_ = x
+}
@@ -0,0 +1,13 @@
+package excludefuncsvalue
+
+type Server struct{}
+
+// "(*Server).Handle" does not name a method with a value receiver.
+func (s Server) Handle() {
+	x := 1
+	{
+		x := 2 // want `variable "x" is redefined`
+		_ = x
+	}
+	_ = x
+}
@@ -0,0 +1,17 @@
+package explain
+
+func f() {
+	x := 1
+	_ = x
+	{
+		x := 2
+		_ = x
+	}
+
+	y := 1
+	{
+		y := 2 // want `variable "y" is redefined`
+		_ = y
+	}
+	_ = y
+}
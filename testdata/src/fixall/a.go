@@ -0,0 +1,45 @@
+package fixall
+
+func f() error { return nil }
+
+// Sibling shadows get distinct names.
+func siblings() error {
+	err := f()
+	{
+		err := f() // want `variable "err" is redefined`
+		_ = err
+	}
+	{
+		err := f() // want `variable "err" is redefined`
+		_ = err
+	}
+	return err
+}
+
+// A closure nested in a renamed block does not reuse the block's name.
+func nested() error {
+	err := f()
+	{
+		err := f() // want `variable "err" is redefined`
+		g := func() error {
+			err := f() // want `variable "err" is redefined`
+			return err
+		}
+		_ = g
+		_ = err
+	}
+	return err
+}
+
+// A variable reported twice is renamed once.
+func twice() int {
+	next := 0
+next:
+	for i := range 3 {
+		next := i // want `variable "next" is redefined` `variable "next" has the same name as the enclosing label`
+		if next > 1 {
+			break next
+		}
+	}
+	return next
+}
@@ -0,0 +1,10 @@
+package forinitallow
+
+func f(xs []int) int {
+	i, n := 0, 0
+	for i := 0; i < len(xs); i++ {
+		n := xs[i] // want `shadows an outer "n" declared at a.go:4:5`
+		_ = n
+	}
+	return i + n
+}
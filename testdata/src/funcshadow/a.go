@@ -0,0 +1,22 @@
+package funcshadow
+
+func load() []string {
+	list := list() // want `variable "list" is redefined and shadows the function "list" declared at b.go:3:6, which cannot be called for the rest of the block$`
+	return list
+}
+
+// Builtins are left to -check-nonvar-outers.
+func builtin(xs []int) int {
+	len := len(xs)
+	return len
+}
+
+func (T) m() {}
+
+type T struct{}
+
+// Methods are not in scope by their bare name.
+func method() {
+	m := 1
+	_ = m
+}
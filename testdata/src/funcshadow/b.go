@@ -0,0 +1,3 @@
+package funcshadow
+
+func list() []string { return nil }
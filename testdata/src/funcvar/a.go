@@ -0,0 +1,22 @@
+package funcvar
+
+func defaultHandler() {}
+func customHandler()  {}
+
+func serve(custom bool) {
+	handler := defaultHandler
+	if custom {
+		handler := customHandler // want `shadows the function value "handler", which is still called`
+		_ = handler
+	}
+	handler()
+}
+
+func once(custom bool) {
+	handler := defaultHandler
+	handler()
+	if custom {
+		handler := customHandler // outer is dead: -allow-dead-outer still applies
+		handler()
+	}
+}
@@ -0,0 +1,10 @@
+package generatedoff
+
+func a() {
+	x := 1
+	{
+		x := 2 // want `shadows an outer "x"`
+		_ = x
+	}
+	_ = x
+}
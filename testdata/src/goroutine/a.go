@@ -0,0 +1,44 @@
+package goroutine
+
+import "sync"
+
+func shared() {
+	var wg sync.WaitGroup
+	total := 0
+	total++
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		total += 1
+		{
+			total := 10 // want `variable "total" is redefined inside a goroutine and shadows "total", which both the goroutine and the function starting it use`
+			_ = total
+		}
+	}()
+	wg.Wait()
+}
+
+// The goroutine alone uses n, so this is an ordinary shadow.
+func private() {
+	n := 0
+	go func() {
+		n++
+		{
+			n := 1 // want `variable "n" is redefined inside a goroutine and hides the outer "n" declared at .*; values assigned to it never reach the outer variable$`
+			_ = n
+		}
+	}()
+}
+
+// A closure that is merely called is no goroutine.
+func called() {
+	n := 0
+	n++
+	func() {
+		n++
+		{
+			n := 1 // want `variable "n" is redefined inside a closure and shadows "n" declared at .* in the enclosing function, which the closure also captures`
+			_ = n
+		}
+	}()
+}
@@ -0,0 +1,28 @@
+package goshadowallow
+
+func compute() int { return 1 }
+
+func f() int {
+	x := 0
+	go func() {
+		x := compute()
+		_ = x
+	}()
+	defer func() {
+		x := compute() // want `variable "x" is redefined inside a deferred closure`
+		_ = x
+	}()
+	return x
+}
+
+// The goroutine's own variables are not covered.
+func g() {
+	go func() {
+		y := 0
+		{
+			y := compute() // want `variable "y" is redefined and shadows an outer "y"`
+			_ = y
+		}
+		_ = y
+	}()
+}
@@ -0,0 +1,57 @@
+package gotolive
+
+func cond() bool { return false }
+
+// The goto runs the check of x again, so x is not dead.
+func retry() int {
+	x := 1
+again:
+	if x > 5 {
+		return 0
+	}
+	{
+		x := 2 // want `variable "x" is redefined`
+		_ = x
+	}
+	if cond() {
+		goto again
+	}
+	return 1
+}
+
+// A goto from within the shadowing statement counts too.
+func inside() int {
+	x := 1
+again:
+	_ = x
+	if x := 2; x > 1 { // want `variable "x" is redefined`
+		goto again
+	}
+	return 1
+}
+
+// Jumping forward leaves x dead.
+func forward() {
+	x := 1
+	_ = x
+	{
+		x := 2
+		_ = x
+	}
+	goto end
+end:
+}
+
+// Jumping back to a point past every use of x leaves it dead too.
+func pastUses() {
+	x := 1
+	_ = x
+again:
+	{
+		x := 2
+		_ = x
+	}
+	if cond() {
+		goto again
+	}
+}
@@ -0,0 +1,31 @@
+package guardlog
+
+import "log"
+
+func g() error { return nil }
+
+func logged() error {
+	err := g()
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+
+	if err := g(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// A body that logs and carries on is not a guard.
+func fallsThrough() error {
+	err := g()
+	if err != nil {
+		log.Print(err)
+	}
+
+	if err := g(); err != nil { // want `variable "err" is redefined`
+		return err
+	}
+	return err
+}
@@ -0,0 +1,22 @@
+package importallow
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+func parse(s string) map[string]any {
+	var m map[string]any
+	json.Unmarshal([]byte(s), &m)
+	return m
+}
+
+func f(s string) ([]byte, error) {
+	if s != "" {
+		json := parse(s)
+		_ = json
+	}
+	strings := strings.Fields(s)
+	_ = strings
+	return json.Marshal(s)
+}
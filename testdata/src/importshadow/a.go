@@ -0,0 +1,22 @@
+package importshadow
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+func parse(s string) map[string]any {
+	var m map[string]any
+	json.Unmarshal([]byte(s), &m)
+	return m
+}
+
+func f(s string) ([]byte, error) {
+	if s != "" {
+		json := parse(s) // want `variable "json" is redefined and shadows the imported package "json" declared at a.go:4:2`
+		_ = json
+	}
+	strings := strings.Fields(s) // want `shadows the imported package "strings"`
+	_ = strings
+	return json.Marshal(s)
+}
@@ -0,0 +1,22 @@
+package initfuncs
+
+func init() {
+	ready := true
+	{
+		ready := false // want `variable "ready" is redefined`
+		_ = ready
+	}
+	_ = ready
+}
+
+type T struct{}
+
+// A method named init is ordinary code.
+func (T) init() {
+	x := 1
+	{
+		x := 2 // want `variable "x" is redefined`
+		_ = x
+	}
+	_ = x
+}
@@ -0,0 +1,23 @@
+package labelname
+
+func find(rows [][]int, want int) (found bool) {
+Loop:
+	for _, row := range rows {
+		for _, v := range row {
+			Loop := v == want // want `variable "Loop" has the same name as the enclosing label declared at a.go:4:1`
+			if Loop {
+				found = true
+				break Loop
+			}
+		}
+	}
+	return
+}
+
+func unrelated(xs []int) int {
+	Done := 0
+	for _, x := range xs {
+		Done += x
+	}
+	return Done
+}
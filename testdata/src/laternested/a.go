@@ -0,0 +1,60 @@
+package laternested
+
+func g(int) {}
+
+// The outer is read in a later nested if body.
+func nestedIf(ok bool) {
+	x := 1
+	if ok {
+		x := 2 // want `variable "x" is redefined`
+		g(x)
+	}
+	if !ok {
+		if x > 0 {
+			g(x)
+		}
+	}
+}
+
+// The outer is read after the shadow, within the same if body.
+func sameBody(ok bool) {
+	x := 1
+	if ok {
+		{
+			x := 2 // want `variable "x" is redefined`
+			g(x)
+		}
+		g(x)
+	}
+}
+
+// The outer is read by a closure defined later.
+func laterClosure(ok bool) func() {
+	x := 1
+	if ok {
+		x := 2 // want `variable "x" is redefined`
+		g(x)
+	}
+	return func() { g(x) }
+}
+
+// The shadow is inside a closure, and the outer is read after it.
+func afterClosure() {
+	x := 1
+	h := func() {
+		x := 2 // want `variable "x" is redefined`
+		g(x)
+	}
+	h()
+	g(x)
+}
+
+// The outer is dead after the shadow, so it stays suppressed.
+func dead(ok bool) {
+	x := 1
+	g(x)
+	if ok {
+		x := 2
+		g(x)
+	}
+}
@@ -0,0 +1,10 @@
+package loopbodyallow
+
+func f(xs []int) int {
+	i, n := 0, 0
+	for i := 0; i < len(xs); i++ { // want `shadows an outer "i" declared at a.go:4:2`
+		n := xs[i]
+		_ = n
+	}
+	return i + n
+}
@@ -0,0 +1,35 @@
+package lostwrite
+
+func compute() int { return 1 }
+func use(int)      {}
+
+func lost(ok bool) {
+	x := 0
+	if ok {
+		x := compute() // want `variable "x" is redefined and shadows "x" declared at a.go:7:2, which is read afterwards on a path where only the shadow was assigned; the write is lost$`
+		_ = x
+	}
+	use(x)
+}
+
+// The shadow's branch returns, so the outer is never read after it.
+func returns(ok bool) int {
+	x := 0
+	if ok {
+		x := compute()
+		return x
+	}
+	return x
+}
+
+// The outer is overwritten before it is read again.
+func overwritten(ok bool) {
+	x := 0
+	use(x)
+	if ok {
+		x := compute() // want `variable "x" is redefined and shadows an outer "x" declared at a.go:27:2$`
+		_ = x
+	}
+	x = 2
+	use(x)
+}
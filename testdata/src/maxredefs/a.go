@@ -0,0 +1,30 @@
+package maxredefs
+
+func f() (int, error) { return 0, nil }
+
+func sequential() error {
+	n, err := f()
+	if n > 0 {
+		_, err := f()
+		_ = err
+	}
+	if n > 1 {
+		_, err := f()
+		_ = err
+	}
+	if n > 2 {
+		_, err := f() // want `variable "err" is redefined`
+		_ = err
+	}
+	return err
+}
+
+// Each function has its own budget.
+func other() error {
+	_, err := f()
+	{
+		_, err := f()
+		_ = err
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package mixedassign
+
+func f() (int, int) { return 0, 0 }
+
+// Only b is new; a is merely assigned, so nothing is redefined.
+func sameScope() int {
+	a, _ := f()
+	a, b := f()
+	return a + b
+}
+
+// Parameters live in the body's scope, so reusing one is an assignment.
+func param(a int) int {
+	a, b := f()
+	return a + b
+}
+
+// In a nested scope both names are new, and a shadows the outer a.
+func nested() int {
+	a, _ := f()
+	if a == 0 {
+		a, b := f() // want `variable "a" is redefined`
+		return a + b
+	}
+	return a
+}
+
+// Within the nested scope, reusing the shadow is again an assignment.
+func nestedReuse() int {
+	a, _ := f()
+	{
+		a, b := f() // want `variable "a" is redefined`
+		a, c := f()
+		_ = a + b + c
+	}
+	return a
+}
+
+// Selectors and index expressions can only be assigned to, so neither
+// declares anything, even next to a shadow.
+func selectors() int {
+	var t struct{ F int }
+	m := map[string]int{}
+	a, _ := f()
+	{
+		a, t.F = f()
+		m["k"], a = f()
+		a, b := f() // want `variable "a" is redefined`
+		_ = a + b
+	}
+	return a + t.F + m["k"]
+}
@@ -0,0 +1,16 @@
+package msgtemplate
+
+func f() error {
+	var err error
+	x := 1
+	if true {
+		x := 2 // want `^\[LINT-7\] redef\.shadow: x shadows x \(a\.go:5:2\)$`
+		_ = x
+		err := g() // want `^\[LINT-7\] redef\.shadow\.err: err shadows err \(a\.go:4:6\)$`
+		_ = err
+	}
+	_ = x
+	return err
+}
+
+func g() error { return nil }
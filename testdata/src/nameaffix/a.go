@@ -0,0 +1,16 @@
+package nameaffix
+
+func f() {
+	_tmp := 1
+	cfgShadow := 1
+	cfgshadow := 1
+	cfg := 1
+	{
+		_tmp := 2
+		cfgShadow := 2
+		cfgshadow := 2 // want `variable "cfgshadow" is redefined`
+		cfg := 2       // want `variable "cfg" is redefined`
+		_, _, _, _ = _tmp, cfgShadow, cfgshadow, cfg
+	}
+	_, _, _, _ = _tmp, cfgShadow, cfgshadow, cfg
+}
@@ -0,0 +1,39 @@
+package namedreturn
+
+import "strconv"
+
+// The body shares a scope with the results, so "n := 5" directly in
+// the body would not compile; a shadow needs a nested block.
+func f(ok bool) (n int) {
+	if ok {
+		n := 5 // want `variable "n" is redefined and shadows the named return value "n"`
+		_ = n
+	}
+	return
+}
+
+func parse(s string) (n int, err error) {
+	if s != "" {
+		n, err := strconv.Atoi(s) // want `variable "n" is redefined and shadows the named return value "n"` `variable "err" is redefined and shadows the named return value "err"`
+		_, _ = n, err
+	}
+	return
+}
+
+func closure() (n int) {
+	g := func() {
+		n := 1 // want `variable "n" is redefined and shadows the named return value "n"`
+		_ = n
+	}
+	g()
+	return
+}
+
+// Parameters are not results.
+func param(n int) int {
+	{
+		n := 1 // want `variable "n" is redefined and shadows the parameter "n"`
+		_ = n
+	}
+	return n
+}
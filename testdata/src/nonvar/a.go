@@ -0,0 +1,44 @@
+package nonvar
+
+import "strings"
+
+const limit = 10
+
+func helper() int { return limit }
+
+type T struct{ len int }
+
+func f(xs []string) int {
+	{
+		limit := 5 // want `variable "limit" is redefined and hides the constant "limit" declared at a.go:5:7`
+		_ = limit
+	}
+	{
+		len := 3 // want `variable "len" is redefined and shadows the builtin function "len"$`
+		_ = len
+	}
+	{
+		helper := func() int { return 0 } // want `variable "helper" is redefined and shadows the function "helper" declared at a.go:7:6`
+		_ = helper
+	}
+	{
+		strings := strings.Join(xs, ",") // want `variable "strings" is redefined and shadows the imported package "strings" declared at a.go:3:8`
+		_ = strings
+	}
+	{
+		true := false // want `shadows the predeclared constant "true"$`
+		_ = true
+	}
+	// Field names live apart from scopes.
+	t := T{len: 1}
+	return t.len
+}
+
+// A local constant hides the package one, and is what gets shadowed.
+func local() {
+	const limit = 1
+	{
+		limit := 2 // want `hides the constant "limit" declared at a.go:39:8`
+		_ = limit
+	}
+}
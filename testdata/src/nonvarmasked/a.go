@@ -0,0 +1,21 @@
+package nonvarmasked
+
+var x = 1
+
+// The constant hides the package variable, so it is the constant
+// which is reported as shadowed.
+func f() int {
+	const x = 2
+	{
+		x := 3 // want `variable "x" is redefined and hides the constant "x" declared at a.go:8:8`
+		_ = x
+	}
+	return x
+}
+
+func len() {
+	{
+		len := 1
+		_ = len
+	}
+}
@@ -0,0 +1,20 @@
+package okshadow
+
+func f(m map[string]int, x any, ch chan int) int {
+	v, ok := m["a"]
+	if ok {
+		w, ok := m["b"] // want `shadows an outer "ok" declared at a.go:4:5`
+		_, _ = w, ok
+	}
+	{
+		s, ok := x.(string) // want `shadows an outer "ok" declared at a.go:4:5`
+		_, _ = s, ok
+	}
+	if ok {
+		n, ok := <-ch // want `shadows an outer "ok" declared at a.go:4:5`
+		_, _ = n, ok
+		v, ok := m["c"] // want `shadows an outer "v" declared at a.go:4:2`
+		_, _ = v, ok
+	}
+	return v
+}
@@ -0,0 +1,14 @@
+package ordering
+
+func b() {
+	y := 1
+	{
+		y := 2 // want `shadows an outer "y"`
+		_ = y
+	}
+	_ = y
+	{
+		y := 3 // want `shadows an outer "y"`
+		_ = y
+	}
+}
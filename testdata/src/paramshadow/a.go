@@ -0,0 +1,42 @@
+package paramshadow
+
+func f(n int) int {
+	if n > 0 {
+		n := 0 // want `variable "n" is redefined and shadows the parameter "n" declared at a.go:3:8`
+		_ = n
+	}
+	return n
+}
+
+// Parameters of an enclosing function literal count too.
+func g() {
+	h := func(s string) {
+		for range 2 {
+			s := "x" // want `shadows the parameter "s"`
+			_ = s
+		}
+		_ = s
+	}
+	h("")
+}
+
+// A local variable is an ordinary outer.
+func local() {
+	v := 1
+	{
+		v := 2 // want `shadows an outer "v"`
+		_ = v
+	}
+	_ = v
+}
+
+type T struct{ n int }
+
+// So does the receiver of a method.
+func (t *T) reset() {
+	if t != nil {
+		t := &T{} // want `variable "t" is redefined and shadows the receiver "t" declared at a.go:36:7, which is unreachable`
+		_ = t
+	}
+	t.n = 0
+}
@@ -0,0 +1,20 @@
+package paramstrict
+
+// With -params-strict, allow-dead-outer does not excuse a parameter
+// shadow.
+func f(n int) {
+	{
+		n := 0 // want `shadows the parameter "n"`
+		_ = n
+	}
+}
+
+// It still applies to other variables.
+func g() {
+	v := 1
+	_ = v
+	{
+		v := 2
+		_ = v
+	}
+}
@@ -0,0 +1,9 @@
+package pkgscope
+
+func f() int {
+	count := 1 // want "redefined"
+	limit := 2 // want "redefined"
+	return count + limit
+}
+
+var limit = 3
@@ -0,0 +1,3 @@
+package pkgscope
+
+var count int
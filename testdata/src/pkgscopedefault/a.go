@@ -0,0 +1,5 @@
+package pkgscopedefault
+
+// total is declared in a file positioned before b.go, which does not
+// make it an outer for b.go by default.
+var total int
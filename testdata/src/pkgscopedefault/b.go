@@ -0,0 +1,9 @@
+package pkgscopedefault
+
+var local int
+
+func f() int {
+	total := 1
+	local := 2 // want `variable "local" is redefined and shadows an outer "local" declared at b.go:3:5`
+	return total + local
+}
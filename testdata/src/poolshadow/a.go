@@ -0,0 +1,49 @@
+package poolshadow
+
+import (
+	"bytes"
+	"sync"
+)
+
+var pool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+var bufs sync.Pool
+
+func fresh(n int) int {
+	buf := pool.Get().(*bytes.Buffer)
+	defer pool.Put(buf)
+	if n > 0 {
+		buf := new(bytes.Buffer) // want `variable "buf" is redefined with a fresh allocation and shadows "buf" obtained from a sync.Pool`
+		return buf.Len()
+	}
+	return buf.Len()
+}
+
+func slice(n int) int {
+	b := bufs.Get().([]byte)
+	if n > len(b) {
+		b := make([]byte, n) // want `variable "b" is redefined with a fresh allocation`
+		return len(b)
+	}
+	return len(b)
+}
+
+// Reslicing the pooled buffer is a deliberate rebind.
+func reslice(n int) int {
+	b := bufs.Get().([]byte)
+	if n < len(b) {
+		b := b[:n] // want `variable "b" is redefined from the outer "b"`
+		return len(b)
+	}
+	return len(b)
+}
+
+// A fresh allocation shadowing a non-pooled value is an ordinary shadow.
+func notPooled(n int) int {
+	b := []byte("x")
+	{
+		b := make([]byte, n) // want `variable "b" is redefined and shadows an outer "b"`
+		_ = b
+	}
+	return len(b)
+}
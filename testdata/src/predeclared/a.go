@@ -0,0 +1,16 @@
+package predeclared
+
+const limit = 3
+
+func f(xs []int) int {
+	len := len(xs)    // want `variable "len" is redefined and shadows the builtin function "len"$`
+	max := 0          // want `shadows the builtin function "max"$`
+	error := "failed" // want `variable "error" is redefined and shadows the predeclared type "error"$`
+	nil := 0          // want `shadows the predeclared identifier "nil"$`
+	var copy []int    // want `shadows the builtin function "copy"$`
+	_, _, _, _ = error, nil, copy, max
+
+	// Constants of the package have their own category.
+	limit := 1 // want `variable "limit" is redefined and hides the constant "limit" declared at a.go:3:7; the rest of the block uses the variable, not the constant$`
+	return len + limit
+}
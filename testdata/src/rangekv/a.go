@@ -0,0 +1,17 @@
+package rangekv
+
+func f(m map[string]int) {
+	k, v := "", 0
+	for k, v := range m { // want `shadows an outer "k" declared at a.go:4:2` `shadows an outer "v" declared at a.go:4:5`
+		_, _ = k, v
+	}
+	for k := range m { // want `shadows an outer "k" declared at a.go:4:2`
+		_ = k
+	}
+	for _, v := range m { // want `shadows an outer "v" declared at a.go:4:5`
+		_ = v
+	}
+	for k, v = range m {
+	}
+	_, _ = k, v
+}
@@ -0,0 +1,17 @@
+package rangekvallow
+
+func f(m map[string]int) {
+	k, v := "", 0
+	for k, v := range m {
+		_, _ = k, v
+	}
+	for k := range m {
+		_ = k
+	}
+	for _, v := range m {
+		_ = v
+	}
+	for k, v = range m {
+	}
+	_, _ = k, v
+}
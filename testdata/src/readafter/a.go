@@ -0,0 +1,56 @@
+package readafter
+
+func compute() int { return 1 }
+func use(int)      {}
+
+func lost(ok bool) {
+	x := 0
+	if ok {
+		x := compute() // want `variable "x" is redefined and shadows an outer "x" declared at a.go:7:2; the outer "x" is read right after the block, at line 12, but never assigned within it, so the value given to the shadow is lost$`
+		_ = x
+	}
+	use(x)
+}
+
+func param(x int, ok bool) int {
+	if ok {
+		x := compute() // want `the outer "x" is read right after the block, at line 20`
+		_ = x
+	}
+	return x
+}
+
+// The block also updates the outer variable.
+func updated(ok bool) {
+	x := 0
+	if ok {
+		x++
+		{
+			x := compute() // want `variable "x" is redefined and shadows an outer "x" declared at a.go:25:2$`
+			_ = x
+		}
+	}
+	use(x)
+}
+
+// Only the statement right after the block counts.
+func later(ok bool) {
+	x := 0
+	if ok {
+		x := compute() // want `variable "x" is redefined and shadows an outer "x" declared at a.go:38:2$`
+		_ = x
+	}
+	use(0)
+	use(x)
+}
+
+// A closure is not run right before what follows it.
+func closure() {
+	x := 0
+	f := func() {
+		x := compute() // want `variable "x" is redefined inside a closure`
+		_ = x
+	}
+	f()
+	use(x)
+}
@@ -0,0 +1,20 @@
+package rebind
+
+import "strings"
+
+func trim(s string, ok bool) string {
+	if ok {
+		s := strings.TrimSpace(s) // want `variable "s" is redefined and shadows the parameter "s"`
+		return s
+	}
+	n := len(s)
+	{
+		n := n + 1 // want `variable "n" is redefined from the outer "n" declared at a.go:10:2, rebinding it for the rest of the block$`
+		_ = n
+	}
+	{
+		n := 2 // want `variable "n" is redefined and shadows an outer "n" declared at a.go:10:2$`
+		_ = n
+	}
+	return s[:n]
+}
@@ -0,0 +1,16 @@
+package rebindallow
+
+import "strings"
+
+func trim(in string) string {
+	s := in
+	{
+		s := strings.TrimSpace(s)
+		_ = s
+	}
+	{
+		s := "" // want `variable "s" is redefined and shadows an outer "s"`
+		_ = s
+	}
+	return s
+}
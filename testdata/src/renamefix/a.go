@@ -0,0 +1,56 @@
+package renamefix
+
+import "reflect"
+
+func f() (int, error) { return 0, nil }
+
+func safe() (int, error) {
+	n, err := f()
+	if n > 0 {
+		n, err := f() // want `variable "n" is redefined` `variable "err" is redefined`
+		return n, err
+	}
+	return n, err
+}
+
+// err2 is taken, so the inner err becomes err3.
+func taken() error {
+	_, err := f()
+	err2 := err
+	{
+		_, err := f() // want `variable "err" is redefined`
+		if err != nil {
+			return err
+		}
+	}
+	return err2
+}
+
+func reflected() int {
+	v := 1
+	{
+		v := 2 // want `variable "v" is redefined`
+		_ = reflect.ValueOf(v)
+	}
+	return v
+}
+
+// Counter returns a closure capturing the shadow, so it is left alone.
+func Counter() func() int {
+	c := 0
+	if c == 0 {
+		c := 1 // want `variable "c" is redefined`
+		return func() int { c++; return c }
+	}
+	return func() int { return c }
+}
+
+// counter is unexported, so its closure may be renamed freely.
+func counter() func() int {
+	c := 0
+	if c == 0 {
+		c := 1 // want `variable "c" is redefined`
+		return func() int { c++; return c }
+	}
+	return func() int { return c }
+}
@@ -0,0 +1,14 @@
+package renamefix
+
+import _ "unsafe"
+
+//go:linkname lk runtime.lk
+
+func linked() int {
+	lk := 1
+	{
+		lk := 2 // want `variable "lk" is redefined`
+		_ = lk
+	}
+	return lk
+}
@@ -0,0 +1,38 @@
+package repeateddecl
+
+func f() int { return 0 }
+
+func siblings(a, b, c bool) int {
+	if a {
+		x := f()
+		_ = x
+	}
+	if b {
+		x := f() // want `variable "x" is declared again in a separate block; the declaration at a.go:7:3 does not persist here`
+		_ = x
+	}
+	if c {
+		x := f() // want `variable "x" is declared again in a separate block; the declaration at a.go:7:3 does not persist here`
+		_ = x
+	}
+	return 0
+}
+
+// Init statements are scoped to their statement by design.
+func inits() int {
+	if x := f(); x > 0 {
+		return x
+	}
+	if x := f(); x < 0 {
+		return -x
+	}
+	return 0
+}
+
+// Each function stands alone.
+func single(a bool) {
+	if a {
+		x := f()
+		_ = x
+	}
+}
@@ -0,0 +1,23 @@
+package sameline
+
+func f() int { return 1 }
+
+func header() {
+	if x := f(); x > 0 { x := 2; _ = x }
+	for i := range 3 { i := i * 2; _ = i }
+	func(n int) { { n := 1; _ = n } }(0)
+}
+
+func separate() {
+	x := 1; { x := 2; _ = x }; _ = x // want `variable "x" is redefined`
+	g := func() { y := 1; func() { y := 2; _ = y }(); _ = y } // want `variable "y" is redefined`
+	g()
+}
+
+// Different lines are out of scope of the rule altogether.
+func lines() {
+	if x := f(); x > 0 {
+		x := 2 // want `variable "x" is redefined`
+		_ = x
+	}
+}
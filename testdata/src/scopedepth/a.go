@@ -0,0 +1,36 @@
+package scopedepth
+
+func f() int { return 0 }
+
+func shallow() int {
+	x := f()
+	if x := f(); x > 0 {
+		return x
+	}
+	if x > 0 {
+		x := f()
+		return x
+	}
+	return x
+}
+
+func deep(xs []int) int {
+	x := f()
+	if x > 0 {
+		for _, v := range xs {
+			x := v // want `variable "x" is redefined`
+			_ = x
+		}
+	}
+	return x
+}
+
+// A function literal's body counts as a single block.
+func closure() int {
+	x := f()
+	g := func() int {
+		x := f()
+		return x
+	}
+	return x + g()
+}
@@ -0,0 +1,15 @@
+package selectoff
+
+func recv(ch chan int, done chan struct{}) int {
+	v := 0
+	select {
+	case v := <-ch:
+		return v
+	case w, ok := <-ch:
+		if ok {
+			return w
+		}
+	case <-done:
+	}
+	return v
+}
@@ -0,0 +1,15 @@
+package selectshadow
+
+func recv(ch chan int, done chan struct{}) int {
+	v := 0
+	select {
+	case v := <-ch: // want `variable "v" is redefined and shadows an outer "v"`
+		return v
+	case w, ok := <-ch:
+		if ok {
+			return w
+		}
+	case <-done:
+	}
+	return v
+}
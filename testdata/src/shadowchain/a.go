@@ -0,0 +1,17 @@
+package shadowchain
+
+func f(x int) int {
+	{
+		x := x + 1 // want `variable "x" is redefined and shadows the parameter "x" declared at a.go:3:8$`
+		{
+			x := 2 // want `variable "x" is redefined and shadows an outer "x" declared at a.go:5:3; "x" is declared 3 times in nested scopes here, also at a.go:3:8$`
+			{
+				x := 0 // want `"x" is declared 4 times in nested scopes here, also at a.go:5:3, a.go:3:8$`
+				_ = x
+			}
+			_ = x
+		}
+		_ = x
+	}
+	return x
+}
@@ -0,0 +1,46 @@
+package shortinit
+
+func f() int { return 0 }
+
+func inits(v any) int {
+	x := f()
+	if x := f(); x > 0 {
+		return x
+	}
+	for x := f(); x < 3; x++ {
+		_ = x
+	}
+	switch x := f(); x {
+	case 1:
+		return x
+	}
+	switch x := f(); y := v.(type) {
+	case int:
+		return x + y
+	}
+	return x
+}
+
+// Only the init slot qualifies, not the statement's body or the
+// binding of a type switch.
+func bodies(v any) int {
+	x := f()
+	if x > 0 {
+		x := f() // want `variable "x" is redefined`
+		_ = x
+	}
+	for i := 0; i < 3; i++ {
+		x := i // want `variable "x" is redefined`
+		_ = x
+	}
+	switch {
+	case x > 1:
+		x := f() // want `variable "x" is redefined`
+		_ = x
+	}
+	switch x := v.(type) { // want `variable "x" is redefined`
+	case int:
+		return x
+	}
+	return x
+}
@@ -0,0 +1,30 @@
+package shortouter
+
+func g() (int, error) { return 0, nil }
+
+// The outer variables are introduced by := rather than var.
+func f() error {
+	x := 1
+	if x > 0 {
+		x := 2 // want `shadows an outer "x" declared at a.go:7:2`
+		_ = x
+	}
+
+	n, err := g()
+	if n, err := g(); err != nil { // want `shadows an outer "n" declared at a.go:13:2` `shadows an outer "err" declared at a.go:13:5`
+		return err
+	} else {
+		_ = n
+	}
+
+	// x is merely assigned to here, so m is the only new variable
+	// and the x below still shadows the one declared at line 7.
+	x, m := 3, 4
+	if m > 0 {
+		x := m // want `shadows an outer "x" declared at a.go:7:2`
+		_ = x
+	}
+
+	_, _ = x, n
+	return err
+}
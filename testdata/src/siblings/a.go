@@ -0,0 +1,57 @@
+package siblings
+
+func cond() bool { return true }
+
+// Variables in sibling branches are unrelated.
+func branches() {
+	if cond() {
+		x := 1
+		_ = x
+	} else {
+		x := 2
+		_ = x
+	}
+
+	switch {
+	case cond():
+		y := 1
+		_ = y
+	default:
+		y := 2
+		_ = y
+	}
+
+	for range 2 {
+		z := 1
+		_ = z
+	}
+	{
+		z := 2
+		_ = z
+	}
+}
+
+// A variable is not in scope within its own declaration.
+func own() {
+	x := func() int {
+		x := 1
+		return x
+	}()
+	_ = x
+
+	var y = func() int {
+		y := 1
+		return y
+	}()
+	_ = y
+}
+
+// Once it is, the shadow counts.
+func after() {
+	x := 1
+	f := func() int {
+		x := 2 // want `variable "x" is redefined`
+		return x
+	}
+	_, _ = x, f
+}
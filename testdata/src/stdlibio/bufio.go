@@ -0,0 +1,51 @@
+package stdlibio
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// countLines is the canonical bufio.Scanner loop.
+func countLines(r io.Reader) (int, error) {
+	sc := bufio.NewScanner(r)
+	n := 0
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" {
+			n++
+		}
+	}
+	return n, sc.Err()
+}
+
+// readLines uses ReadString, checking err after using the line.
+func readLines(r io.Reader) (lines []string, err error) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n') // want `variable "err" is redefined`
+		if line != "" {
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
+		}
+	}
+}
+
+// flush writes through a bufio.Writer, reusing err in one scope.
+func flush(w io.Writer, s string) error {
+	bw := bufio.NewWriter(w)
+	_, err := bw.WriteString(s)
+	if err != nil {
+		return err
+	}
+	_, err = bw.WriteString("\n")
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
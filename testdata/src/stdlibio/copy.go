@@ -0,0 +1,71 @@
+package stdlibio
+
+import (
+	"errors"
+	"io"
+)
+
+var errInvalidWrite = errors.New("invalid write result")
+
+// copyBuffer follows io.copyBuffer: nr/er and nw/ew are declared anew
+// on every iteration, in a scope of their own, and shadow nothing.
+func copyBuffer(dst io.Writer, src io.Reader, buf []byte) (written int64, err error) {
+	for {
+		nr, er := src.Read(buf)
+		if nr > 0 {
+			nw, ew := dst.Write(buf[0:nr])
+			if nw < 0 || nr < nw {
+				nw = 0
+				if ew == nil {
+					ew = errInvalidWrite
+				}
+			}
+			written += int64(nw)
+			if ew != nil {
+				err = ew
+				break
+			}
+			if nr != nw {
+				err = io.ErrShortWrite
+				break
+			}
+		}
+		if er != nil {
+			if er != io.EOF {
+				err = er
+			}
+			break
+		}
+	}
+	return written, err
+}
+
+// writeAll reuses n and err in one scope; every := after the first
+// merely assigns.
+func writeAll(w io.Writer, parts ...[]byte) (int, error) {
+	n, err := w.Write(parts[0])
+	if err != nil {
+		return n, err
+	}
+	total := n
+	n, err = w.Write(parts[1])
+	total += n
+	if err != nil {
+		return total, err
+	}
+	m, err := w.Write(parts[2])
+	return total + m, err
+}
+
+// readLoop redeclares n and err inside the loop body, so the outer
+// pair never sees what was read: a genuine shadow.
+func readLoop(r io.Reader, buf []byte) (int, error) {
+	n, err := r.Read(buf)
+	for err == nil && n < len(buf) {
+		n, err := r.Read(buf[n:]) // want `variable "n" is redefined` `variable "err" is redefined`
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
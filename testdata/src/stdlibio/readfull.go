@@ -0,0 +1,33 @@
+package stdlibio
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// readHeader follows the io.ReadFull idiom with sequential reads in
+// one scope.
+func readHeader(r io.Reader) (uint32, []byte, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(hdr[:])
+	body := make([]byte, size)
+	n, err := io.ReadFull(r, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return size, body[:n], nil
+}
+
+// readChunks nests a second read inside the error branch of the first
+// and shadows its results.
+func readChunks(r io.Reader, a, b []byte) (int, error) {
+	n, err := io.ReadFull(r, a)
+	if err == io.ErrUnexpectedEOF {
+		n, err := io.ReadFull(r, b[:n]) // want `variable "n" is redefined` `variable "err" is redefined`
+		return n, err
+	}
+	return n, err
+}
@@ -0,0 +1,3 @@
+package subtest
+
+func f() int { return 1 }
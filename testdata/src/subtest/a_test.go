@@ -0,0 +1,21 @@
+package subtest
+
+import "testing"
+
+func other(t *testing.T) *testing.T { return t }
+
+func TestF(t *testing.T) {
+	t.Run("x", func(t *testing.T) {
+		if f() == 1 {
+			t := other(t) // want `shadows the \*testing.T parameter "t"`
+			t.Log("shadowed")
+		}
+	})
+}
+
+func BenchmarkF(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b := &testing.B{} // want `shadows the \*testing.B parameter "b"`
+		_ = b
+	}
+}
@@ -0,0 +1,70 @@
+package suggestreuse
+
+func f() (int, error) { return 0, nil }
+
+func same() int {
+	n := 1
+	if n > 0 {
+		n := 2 // want `variable "n" is redefined and shadows an outer "n" declared at a.go:6:2; consider assigning to the outer variable with '=' instead of ':='`
+		_ = n
+	}
+	return n
+}
+
+// Both variables shadow, so one fix covers the statement.
+func pair() (int, error) {
+	n, err := f()
+	{
+		n, err := f() // want `consider assigning` `consider assigning`
+		_, _ = n, err
+	}
+	return n, err
+}
+
+// m is new, so the statement needs :=, or m a declaration of its own.
+func fresh() error {
+	_, err := f()
+	{
+		m, err := f() // want `variable "err" is redefined and shadows an outer "err" declared at a.go:26:5; "m" is new, so assigning to the outer variable with '=' requires declaring it separately$`
+		_, _ = m, err
+	}
+	return err
+}
+
+// A different type keeps the rename.
+func retyped() any {
+	var v any = 1
+	{
+		v := 2 // want `variable "v" is redefined with a different type \(int vs any\) and shadows an outer "v" declared at a.go:36:6$`
+		_ = v
+	}
+	return v
+}
+
+// The init statement of an if can assign too.
+func init_() error {
+	_, err := f()
+	if _, err := f(); err != nil { // want `consider assigning`
+		return err
+	}
+	return err
+}
+
+// Range keys and values stay per-iteration.
+func ranged(xs []int) int {
+	i := 0
+	for i := range xs { // want `variable "i" is redefined and shadows an outer "i" declared at a.go:55:2$`
+		_ = i
+	}
+	return i
+}
+
+// Several names may be new.
+func many() error {
+	_, err := f()
+	{
+		a, b, err := 1, 2, error(nil) // want `"a" and "b" are new, so assigning to the outer variable with '=' requires declaring them separately$`
+		_, _, _ = a, b, err
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package summary
+
+func f() error { return nil }
+
+type T struct{}
+
+func (T) Foo(xs []int) error { // want `func \(T\) Foo: 3 shadows \(2 err, 1 loop\)`
+	err := f()
+	n := 0
+	if err != nil {
+		err := f()
+		_ = err
+	}
+	for _, x := range xs {
+		n := x
+		_ = n
+	}
+	{
+		err := f()
+		_ = err
+	}
+	_ = n
+	return err
+}
+
+func (*T) Bar() int { // want `func \(\*T\) Bar: 1 shadow \(1 plain\)`
+	v := 1
+	g := func() { // want `func literal at a.go:28:7: 1 shadow \(1 closure\)`
+		v := 2
+		_ = v
+	}
+	g()
+	{
+		v := 3
+		_ = v
+	}
+	return v
+}
+
+func clean() int {
+	v := 1
+	return v
+}
@@ -0,0 +1,21 @@
+package summaryrepeated
+
+func first() { // want `func first: 1 shadow \(1 repeated-decl\)`
+	{
+		x := 1
+		_ = x
+	}
+	{
+		x := 2
+		_ = x
+	}
+}
+
+func second() int { // want `func second: 1 shadow \(1 plain\)`
+	v := 1
+	{
+		v := 2
+		_ = v
+	}
+	return v
+}
@@ -0,0 +1,67 @@
+package syncshadow
+
+import "sync"
+
+type cache struct {
+	mu sync.Mutex
+	m  map[string]int
+}
+
+func (c *cache) get(k string) int {
+	mu := &c.mu
+	mu.Lock()
+	defer mu.Unlock()
+	{
+		mu := &sync.Mutex{} // want `variable "mu" is redefined and shadows "mu" declared at a.go:11:2, a \*sync.Mutex; locking it in the rest of the block does not exclude holders of the outer lock`
+		mu.Lock()
+		defer mu.Unlock()
+	}
+	return c.m[k]
+}
+
+func rw() {
+	var mu sync.RWMutex
+	mu.RLock()
+	defer mu.RUnlock()
+	if true {
+		var mu sync.RWMutex // want `a sync.RWMutex; locking it`
+		mu.Lock()
+		mu.Unlock()
+	}
+}
+
+func wait(jobs []func()) {
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		var wg sync.WaitGroup // want `variable "wg" is redefined and shadows "wg" declared at a.go:34:6, a sync.WaitGroup; Add, Done and Wait in the rest of the block count a different group than the outer one`
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job()
+		}()
+	}
+	wg.Wait()
+}
+
+func once(init func()) {
+	var once sync.Once
+	{
+		once := new(sync.Once) // want `a sync.Once; Do in the rest of the block does not share the outer's once-only guarantee`
+		once.Do(init)
+	}
+	once.Do(init)
+}
+
+// Other sync types, and other types named alike, are plain shadows.
+type Mutex struct{}
+
+func other() {
+	m := new(sync.Map)
+	var mu Mutex
+	{
+		m := &sync.Map{} // want `variable "m" is redefined and shadows an outer "m"`
+		mu := Mutex{}    // want `variable "mu" is redefined and shadows an outer "mu"`
+		_, _ = m, mu
+	}
+	_, _ = m, mu
+}
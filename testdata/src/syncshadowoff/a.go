@@ -0,0 +1,16 @@
+package syncshadowoff
+
+import "sync"
+
+func wait(jobs []func()) {
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		var wg sync.WaitGroup // want `variable "wg" is redefined and shadows an outer "wg"`
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			job()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,14 @@
+package tablerenames
+
+import "testing"
+
+var cases = []int{1, 2}
+
+func TestRenamed(t *testing.T) {
+	tc := cases[0]
+	for _, tt := range cases {
+		tc := tt
+		t.Run("", func(t *testing.T) { _ = tc })
+	}
+	_ = tc
+}
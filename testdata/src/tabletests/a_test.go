@@ -0,0 +1,52 @@
+package tabletests
+
+import "testing"
+
+func TestCases(t *testing.T) {
+	for _, tt := range cases {
+		tt := tt
+		t.Run("", func(t *testing.T) { _ = tt })
+	}
+}
+
+// Renaming the copy takes -table-test-renames.
+func TestRenamed(t *testing.T) {
+	tc := cases[0]
+	for _, tt := range cases {
+		tc := tt // want `variable "tc" is redefined`
+		t.Run("", func(t *testing.T) { _ = tc })
+	}
+	_ = tc
+}
+
+// Only a direct statement of the range body qualifies.
+func TestNested(t *testing.T) {
+	for _, tt := range cases {
+		if tt.in > 0 {
+			tt := tt // want `variable "tt" is redefined`
+			_ = tt
+		}
+	}
+}
+
+var byCase = map[testCase]string{{1, 1}: "one"}
+
+// The table may be ranged over by key, too.
+func TestKeys(t *testing.T) {
+	for tt := range byCase {
+		tt := tt
+		t.Run("", func(t *testing.T) { _ = tt })
+	}
+	for tt, name := range byCase {
+		tt := tt
+		t.Run(name, func(t *testing.T) { _ = tt })
+	}
+}
+
+// A blank key leaves the value to pair with the copy.
+func TestBlankKey(t *testing.T) {
+	for _, tt := range byCase {
+		tt := tt
+		t.Run(tt, func(t *testing.T) {})
+	}
+}
@@ -0,0 +1,23 @@
+package tabletests
+
+type testCase struct{ in, want int }
+
+var cases = []testCase{{1, 1}, {2, 2}}
+
+func check(func()) {}
+
+// RunTableCases matches -table-test-funcs.
+func RunTableCases() {
+	for _, tt := range cases {
+		tt := tt
+		check(func() { _ = tt })
+	}
+}
+
+// process is ordinary code, however much it looks like a table test.
+func process() {
+	for _, tt := range cases {
+		tt := tt // want `variable "tt" is redefined`
+		check(func() { _ = tt })
+	}
+}
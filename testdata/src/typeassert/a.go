@@ -0,0 +1,35 @@
+package typeassert
+
+func compute() string { return "" }
+
+func discarded(x any, fresh bool) string {
+	if v, ok := x.(string); ok {
+		if fresh {
+			v := compute() // want `shadows "v" bound by the enclosing type assertion`
+			return v
+		}
+		return v
+	}
+	return ""
+}
+
+func readFirst(x any) string {
+	if v, ok := x.(string); ok && v != "" {
+		if len(v) > 1 {
+			v := compute() // want `shadows an outer "v"`
+			return v
+		}
+	}
+	return ""
+}
+
+type node struct{ inner *node }
+
+// Deriving the shadow from the asserted value reads it.
+func unwrap(x any) *node {
+	if v, ok := x.(*node); ok {
+		v := v.inner // want `variable "v" is redefined from the outer "v"`
+		return v
+	}
+	return nil
+}
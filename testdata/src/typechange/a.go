@@ -0,0 +1,35 @@
+package typechange
+
+type MyError struct{}
+
+func (*MyError) Error() string { return "mine" }
+
+func plain() error       { return nil }
+func concrete() *MyError { return nil }
+
+func differ() error {
+	err := plain()
+	if err != nil {
+		err := concrete() // want `variable "err" is redefined with a different type \(\*MyError vs error\) and shadows an outer "err" declared at a.go:11:2`
+		return err
+	}
+	return err
+}
+
+func same() error {
+	err := plain()
+	if err != nil {
+		err := plain()
+		return err
+	}
+	return err
+}
+
+func untypedConst() int64 {
+	var n int64 = 1
+	{
+		n := 2 // want `variable "n" is redefined with a different type \(int vs int64\)`
+		_ = n
+	}
+	return n
+}
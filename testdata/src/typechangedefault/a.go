@@ -0,0 +1,27 @@
+package typechangedefault
+
+type MyError struct{}
+
+func (*MyError) Error() string { return "mine" }
+
+func plain() error       { return nil }
+func concrete() *MyError { return nil }
+
+func count() int64 {
+	var n int64 = 1
+	{
+		n := 2 // want `variable "n" is redefined with a different type \(int vs int64\) and shadows an outer "n" declared at a.go:11:6`
+		_ = n
+	}
+	return n
+}
+
+// Without -warn-type-change, the allow-* rules still apply.
+func allowed() error {
+	err := plain()
+	if err != nil {
+		err := concrete()
+		return err
+	}
+	return err
+}
@@ -0,0 +1,8 @@
+package typedecloff
+
+type config struct{}
+
+func f() {
+	type config int
+	_ = config(0)
+}
@@ -0,0 +1,24 @@
+package typeshadow
+
+func load(v any) bool {
+	type config struct{ name string } // want `type "config" is redeclared and hides the type "config" declared at b.go:3:6; conversions and type assertions in the rest of the block refer to the local type$`
+	_, ok := v.(config)
+	return ok
+}
+
+var settings = 1
+
+// Only types count as outers of a type.
+func named() int {
+	type settings int
+	return int(settings(2))
+}
+
+func nested() {
+	type local int
+	{
+		type local string // want `type "local" is redeclared and hides the type "local" declared at a.go:18:7`
+		_ = local("")
+	}
+	_ = local(0)
+}
@@ -0,0 +1,5 @@
+package typeshadow
+
+type config struct{ path string }
+
+var _ = settings
@@ -0,0 +1,25 @@
+package typeswitch
+
+func f(x any) int {
+	v := 0
+	switch v := x.(type) { // want `variable "v" is redefined and shadows an outer "v"`
+	case int:
+		return v
+	case string:
+		return len(v)
+	case nil:
+		return -1
+	}
+
+	switch s := x.(type) {
+	case string:
+		return len(s) + v
+	}
+
+	switch x := x.(type) {
+	case int:
+		return x
+	}
+
+	return v
+}
@@ -0,0 +1,54 @@
+package unusedinner
+
+func f() int { return 0 }
+func g(int)  {}
+
+func used() int {
+	x := f()
+	{
+		x := f() // want `variable "x" is redefined and shadows an outer "x" declared at a.go:7:2$`
+		g(x)
+	}
+	return x
+}
+
+func blank() int {
+	x := f()
+	{
+		x := f() // want `variable "x" is redefined and shadows an outer "x" declared at a.go:16:2; the inner variable is never used`
+		_ = x
+	}
+	return x
+}
+
+func inClosure() int {
+	x := f()
+	{
+		x := f() // want `variable "x" is redefined and shadows an outer "x" declared at a.go:25:2$`
+		_ = func() { g(x) }
+	}
+	return x
+}
+
+// Later writes are no reads; dropping the declaration would turn them
+// into writes of the outer variable, so none is suggested.
+func written() int {
+	x := f()
+	{
+		x := f() // want `variable "x" is redefined and shadows an outer "x" declared at a.go:36:2; the inner variable is only ever assigned to, never read$`
+		x = f()
+		_ = x
+	}
+	return x
+}
+
+// Compound assignments read the variable.
+func incremented() int {
+	x := f()
+	{
+		x := f() // want `variable "x" is redefined and shadows an outer "x" declared at a.go:47:2$`
+		x += f()
+		_ = x
+	}
+	return x
+}
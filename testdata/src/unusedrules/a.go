@@ -0,0 +1,11 @@
+package unusedrules
+
+func g() error { return nil }
+
+func f() error {
+	err := g()
+	if err := g(); err != nil {
+		return err
+	}
+	return err
+}
@@ -0,0 +1,30 @@
+package vardecl
+
+var pkgLevel int
+
+func g() error { return nil }
+
+func f() error {
+	err := g()
+	if err != nil {
+		var err error // want `shadows an outer "err" declared at a.go:8:2`
+		_ = err
+	}
+
+	x := 1
+	{
+		var x, y = 2, 3 // want `shadows an outer "x" declared at a.go:14:2`
+		var (
+			pkgLevel = 4 // want `shadows an outer "pkgLevel" declared at a.go:3:5`
+			fresh    int
+		)
+		_, _, _, _ = x, y, pkgLevel, fresh
+	}
+
+	{
+		const x = 5
+		_ = x
+	}
+	_ = x
+	return err
+}
@@ -0,0 +1,30 @@
+package vardecloff
+
+var pkgLevel int
+
+func g() error { return nil }
+
+func f() error {
+	err := g()
+	if err != nil {
+		var err error
+		_ = err
+	}
+
+	x := 1
+	{
+		var x, y = 2, 3
+		var (
+			pkgLevel = 4
+			fresh    int
+		)
+		_, _, _, _ = x, y, pkgLevel, fresh
+	}
+
+	{
+		const x = 5
+		_ = x
+	}
+	_ = x
+	return err
+}
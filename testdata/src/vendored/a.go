@@ -0,0 +1,12 @@
+package vendored
+
+import "example.com/dep"
+
+func f() int {
+	x := dep.F()
+	{
+		x := 2 // want `variable "x" is redefined`
+		_ = x
+	}
+	return x
+}